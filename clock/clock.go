@@ -0,0 +1,26 @@
+package clock
+
+import "time"
+
+// Clock is the source of time for anything that schedules work, so tests can
+// swap in a fake and move time forward without sleeping.
+type Clock interface {
+	Now() time.Time
+	AfterFunc(time.Duration, func()) Timer
+}
+
+// Timer cancels a func scheduled with AfterFunc. *time.Timer is one.
+type Timer interface {
+	Stop() bool
+}
+
+// Real is the Clock backed by the time package.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
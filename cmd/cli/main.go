@@ -1,29 +1,138 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
+	"github.com/shortykevich/go-with-tests-app/clock"
 	fss "github.com/shortykevich/go-with-tests-app/db/fs_storage"
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 	"github.com/shortykevich/go-with-tests-app/poker"
 )
 
 var dummyAlerter = &poker.SpyBlindAlerter{}
 
-const dbFileName = "game.db.json"
+const (
+	pointsOrder = "points"
+	newSession  = "new"
+)
+
+var (
+	storageMode = flag.String("storage", fss.FileMode, "storage backend, \"file\" or \"log\"")
+	leagueOrder = flag.String("league", "", "print the league ordered by \"wins\", \"rating\", \"winnings\" or \"points\" and exit")
+	blindsFile  = flag.String("blinds", "", "JSON file with extra blind structures")
+	pointsTable = flag.String("points", "", "comma separated points for each place, F1 points if empty")
+	buyIn       = flag.Int("buyin", 0, "buy-in of every game, for its prize pool")
+	ledger      = flag.String("ledger", "", "record buy-ins and cash-outs for a \"new\" home game session, or the session with this ID")
+	payouts     = flag.String("payouts", "", "comma separated percentage of the prize pool paid to each place, 50,30,20 if empty")
+	odds        = flag.String("odds", "", "print how often each of the comma separated hands wins, e.g. \"Ah Kd, Qs Qc\", and exit")
+	board       = flag.String("board", "", "cards already on the board for -odds, e.g. \"2c 7d 9h\"")
+	samples     = flag.Int("samples", 0, "deal this many random boards for -odds instead of every board")
+	seed        = flag.Uint64("seed", 0, "seed for the boards -samples deals, random if 0")
+)
 
 func main() {
-	fmt.Println("Let's play poker!")
-	fmt.Println(`Type "{Name} wins" to record a win`)
+	flag.Parse()
+
+	if *odds != "" {
+		printOdds(*odds, *board)
+		return
+	}
 
-	storage, close, err := fss.FileSystemStorageFromFile(dbFileName)
+	storage, close, err := fss.OpenStorage(*storageMode, fss.DefaultPath(*storageMode))
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer close()
 
-	game := poker.NewTexasHoldem(poker.BlindAlerterFunc(poker.Alerter), storage)
+	if *ledger != "" {
+		runLedger(storage, *ledger)
+		return
+	}
+
+	switch *leagueOrder {
+	case "":
+	case pointsOrder:
+		table, err := leaguedb.NewPointsTable(*pointsTable)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printStandings(storage, table)
+		return
+	default:
+		printLeague(storage, *leagueOrder)
+		return
+	}
+
+	fmt.Println("Let's play poker!")
+	fmt.Println(`Type "{Name} wins" to record a win, or the finishing order like "Ruth, Chris, Cleo"`)
+	fmt.Println(`Type "out {Name}", "rebuy {Name}" or "addon {Name}" as players bust, rebuy or take an add-on`)
+
+	game := poker.NewTexasHoldem(poker.NewAlerter(clock.Real{}), storage)
+	if err := game.AddBlindStructuresFromFile(*blindsFile); err != nil {
+		log.Fatal(err)
+	}
+	structure, err := leaguedb.NewPayoutStructure(*payouts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	game.WithBuyIn(*buyIn).WithPayoutStructure(structure)
+	fmt.Printf("Follow the number of players with one of %v to pick the blinds\n", game.BlindStructureNames())
 	cli := poker.NewCLI(os.Stdin, os.Stdout, game)
 	cli.PlayPoker()
 }
+
+func runLedger(storage leaguedb.PlayersStorage, session string) {
+	ledger, ok := storage.(leaguedb.LedgerStorage)
+	if !ok {
+		log.Fatalf("%s storage doesn't keep a ledger", *storageMode)
+	}
+	if session == newSession {
+		started, err := ledger.StartSession(time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		session = started.ID
+	}
+
+	fmt.Printf("Ledger for session %s\n", session)
+	poker.NewLedgerCLI(os.Stdin, os.Stdout, ledger, session).Run()
+}
+
+func printLeague(storage leaguedb.PlayersStorage, order string) {
+	league, err := storage.GetLeagueTable()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := league.OrderBy(order); err != nil {
+		log.Fatal(err)
+	}
+	poker.PrintLeague(os.Stdout, league)
+}
+
+func printStandings(storage leaguedb.PlayersStorage, table leaguedb.PointsTable) {
+	games, err := storage.GetGames()
+	if err != nil {
+		log.Fatal(err)
+	}
+	poker.PrintStandings(os.Stdout, leaguedb.Standings(games, table))
+}
+
+func printOdds(hands, board string) {
+	config := poker.OddsConfig{Samples: *samples, Seed: *seed}
+	var err error
+	if config.Hands, err = poker.ParseHands(hands); err != nil {
+		log.Fatal(err)
+	}
+	if config.Board, err = poker.ParseCards(board); err != nil {
+		log.Fatal(err)
+	}
+	result, err := poker.CalculateOdds(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	poker.PrintOdds(os.Stdout, result)
+}
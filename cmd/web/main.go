@@ -1,32 +1,56 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 
+	"github.com/shortykevich/go-with-tests-app/clock"
 	fss "github.com/shortykevich/go-with-tests-app/db/fs_storage"
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 	"github.com/shortykevich/go-with-tests-app/poker"
 	"github.com/shortykevich/go-with-tests-app/webserver"
 )
 
-const (
-	port       = ":5000"
-	dbFileName = "game.db.json"
+const port = ":5000"
+
+var (
+	storageMode = flag.String("storage", fss.FileMode, "storage backend, \"file\" or \"log\"")
+	blindsFile  = flag.String("blinds", "", "JSON file with extra blind structures")
+	pointsTable = flag.String("points", "", "comma separated points for each place, F1 points if empty")
+	buyIn       = flag.Int("buyin", 0, "buy-in of every game, for its prize pool")
+	payouts     = flag.String("payouts", "", "comma separated percentage of the prize pool paid to each place, 50,30,20 if empty")
 )
 
 func main() {
-	storage, close, err := fss.FileSystemStorageFromFile(dbFileName)
+	flag.Parse()
+
+	path := fss.DefaultPath(*storageMode)
+	storage, close, err := fss.OpenStorage(*storageMode, path)
 	if err != nil {
-		log.Fatalf("problem opening %s %v", dbFileName, err)
+		log.Fatalf("problem opening %s %v", path, err)
 	}
 	defer close()
 
-	game := poker.NewTexasHoldem(poker.BlindAlerterFunc(poker.Alerter), storage)
+	game := poker.NewTexasHoldem(poker.NewAlerter(clock.Real{}), storage)
+	if err := game.AddBlindStructuresFromFile(*blindsFile); err != nil {
+		log.Fatal(err)
+	}
+	structure, err := leaguedb.NewPayoutStructure(*payouts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	game.WithBuyIn(*buyIn).WithPayoutStructure(structure)
 
 	handler, err := webserver.NewPlayersScoreServer(storage, game)
 	if err != nil {
 		log.Fatalf("problem creating player server %v", err)
 	}
+	table, err := leaguedb.NewPointsTable(*pointsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+	handler.WithPointsTable(table)
 
 	log.Printf("Listening on port %v", port)
 	log.Fatal(http.ListenAndServe(port, handler))
@@ -0,0 +1,374 @@
+package fss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+)
+
+const (
+	DefaultCompactEvery = 100
+	snapshotSuffix      = ".snapshot"
+
+	eventWin     = "win"
+	eventDelete  = "delete"
+	eventRename  = "rename"
+	eventGame    = "game"
+	eventSession = "session"
+	eventLedger  = "ledger"
+)
+
+// EventLogPlayerStorage appends every change to the log as one JSON event per line
+// instead of rewriting the whole league. Every compactEvery events the league is
+// written to a snapshot file and the log is truncated. Like the file storage it
+// reloads under an advisory lock, so other processes' events are never lost.
+type EventLogPlayerStorage struct {
+	mu           sync.Mutex
+	log          *os.File
+	snapshotPath string
+	compactEvery int
+	seq          int
+	pending      int
+	Doc          leaguedb.Document
+}
+
+type event struct {
+	Seq     int
+	Type    string
+	Name    string
+	NewName string                `json:",omitempty"`
+	Game    *leaguedb.GameRecord  `json:",omitempty"`
+	Session *leaguedb.Session     `json:",omitempty"`
+	Entry   *leaguedb.LedgerEntry `json:",omitempty"`
+}
+
+// snapshot.League holds a league document, or a bare league array in snapshots
+// written before the schema was versioned; NewDocument migrates either.
+type snapshot struct {
+	Seq    int
+	League json.RawMessage
+}
+
+func EventLogStorageFromFile(path string) (*EventLogPlayerStorage, func(), error) {
+	log, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+
+	close := func() {
+		log.Close()
+	}
+
+	store, err := NewEventLogPlayerStorage(log, path+snapshotSuffix, DefaultCompactEvery)
+	if err != nil {
+		log.Close()
+		return nil, nil, fmt.Errorf("problem creating event log player store, %v", err)
+	}
+	return store, close, nil
+}
+
+func NewEventLogPlayerStorage(log *os.File, snapshotPath string, compactEvery int) (*EventLogPlayerStorage, error) {
+	store := &EventLogPlayerStorage{
+		log:          log,
+		snapshotPath: snapshotPath,
+		compactEvery: compactEvery,
+	}
+
+	unlock, err := lockFile(log.Name()+lockSuffix, true)
+	if err != nil {
+		return nil, fmt.Errorf("problem locking %s, %v", log.Name(), err)
+	}
+	defer unlock()
+
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// load rebuilds the league from the snapshot and the log. The log never grows
+// past compactEvery events, so doing this before every change stays cheap.
+func (e *EventLogPlayerStorage) load() error {
+	e.Doc = leaguedb.NewEmptyDocument()
+	e.seq = 0
+	e.pending = 0
+
+	if err := e.loadSnapshot(); err != nil {
+		return fmt.Errorf("problem loading snapshot %s, %v", e.snapshotPath, err)
+	}
+	if err := e.replay(); err != nil {
+		return fmt.Errorf("problem replaying event log %s, %v", e.log.Name(), err)
+	}
+	return nil
+}
+
+func (e *EventLogPlayerStorage) loadShared() error {
+	unlock, err := lockFile(e.log.Name()+lockSuffix, false)
+	if err != nil {
+		return fmt.Errorf("problem locking %s, %v", e.log.Name(), err)
+	}
+	defer unlock()
+
+	return e.load()
+}
+
+func (e *EventLogPlayerStorage) loadSnapshot() error {
+	data, err := os.ReadFile(e.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	e.seq = snap.Seq
+	doc, err := leaguedb.NewDocument(bytes.NewReader(snap.League))
+	if err != nil {
+		return err
+	}
+	e.Doc = doc
+	return nil
+}
+
+// replay applies the events that are newer than the snapshot. A torn last line
+// left by a crash mid-append is cut off so new events start on a clean line.
+func (e *EventLogPlayerStorage) replay() error {
+	if _, err := e.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(e.log)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				return e.log.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var ev event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return fmt.Errorf("problem parsing event at offset %d, %v", offset, err)
+		}
+		offset += int64(len(line))
+
+		if ev.Seq <= e.seq {
+			continue
+		}
+		if err := applyEvent(&e.Doc, ev); err != nil {
+			return fmt.Errorf("problem applying event %d, %v", ev.Seq, err)
+		}
+		e.seq = ev.Seq
+		e.pending++
+	}
+}
+
+func applyEvent(doc *leaguedb.Document, ev event) error {
+	switch ev.Type {
+	case eventWin:
+		doc.Players.RecordWin(ev.Name)
+	case eventDelete:
+		return doc.Players.Delete(ev.Name)
+	case eventRename:
+		return doc.Players.Rename(ev.Name, ev.NewName)
+	case eventGame:
+		if ev.Game == nil {
+			return errors.New("game event without a game")
+		}
+		doc.AddGame(*ev.Game)
+	case eventSession:
+		if ev.Session == nil {
+			return errors.New("session event without a session")
+		}
+		doc.StartSession(ev.Session.StartedAt)
+	case eventLedger:
+		if ev.Entry == nil {
+			return errors.New("ledger event without an entry")
+		}
+		return doc.AddLedgerEntry(ev.Name, *ev.Entry)
+	default:
+		return fmt.Errorf("unknown event type %q", ev.Type)
+	}
+	return nil
+}
+
+// append catches up with the log under the exclusive lock and applies the event
+// to a copy of the league first, so an event that fails to apply is never
+// written to the log. Once the event is synced it is recorded, so a failed
+// compaction is only logged and tried again on the next append.
+func (e *EventLogPlayerStorage) append(ev event) error {
+	unlock, err := lockFile(e.log.Name()+lockSuffix, true)
+	if err != nil {
+		return fmt.Errorf("problem locking %s, %v", e.log.Name(), err)
+	}
+	defer unlock()
+
+	if err := e.load(); err != nil {
+		return err
+	}
+
+	ev.Seq = e.seq + 1
+	doc := e.Doc.Clone()
+	if err := applyEvent(&doc, ev); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("problem encoding event, %v", err)
+	}
+	if _, err := e.log.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("problem seeking to end of %s, %v", e.log.Name(), err)
+	}
+	if _, err := e.log.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("problem appending to %s, %v", e.log.Name(), err)
+	}
+	if err := e.log.Sync(); err != nil {
+		return fmt.Errorf("problem syncing %s, %v", e.log.Name(), err)
+	}
+
+	e.Doc = doc
+	e.seq = ev.Seq
+	e.pending++
+
+	if e.pending >= e.compactEvery {
+		if err := e.compact(); err != nil {
+			log.Printf("problem compacting %s, will retry, %v", e.log.Name(), err)
+		}
+	}
+	return nil
+}
+
+// compact writes the current league to the snapshot and truncates the log. The
+// tape syncs the snapshot and its directory before it returns, and the snapshot
+// remembers the last event it contains, so a crash before the truncate only
+// leaves events that replay will skip.
+func (e *EventLogPlayerStorage) compact() error {
+	doc, err := json.Marshal(e.Doc)
+	if err != nil {
+		return fmt.Errorf("problem encoding snapshot, %v", err)
+	}
+	data, err := json.Marshal(snapshot{Seq: e.seq, League: doc})
+	if err != nil {
+		return fmt.Errorf("problem encoding snapshot, %v", err)
+	}
+
+	if _, err := (&tape{path: e.snapshotPath}).Write(data); err != nil {
+		return fmt.Errorf("problem writing snapshot %s, %v", e.snapshotPath, err)
+	}
+	if err := e.log.Truncate(0); err != nil {
+		return fmt.Errorf("problem truncating %s, %v", e.log.Name(), err)
+	}
+
+	e.pending = 0
+	return nil
+}
+
+func (e *EventLogPlayerStorage) PostPlayerScore(player string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.append(event{Type: eventWin, Name: player})
+}
+
+func (e *EventLogPlayerStorage) GetLeagueTable() (leaguedb.League, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.loadShared(); err != nil {
+		return nil, err
+	}
+	e.Doc.Players.SortByWins()
+	return e.Doc.Players, nil
+}
+
+func (e *EventLogPlayerStorage) GetPlayerScore(player string) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.loadShared(); err != nil {
+		return 0, err
+	}
+	if p := e.Doc.Players.Find(player); p != nil {
+		return p.Wins, nil
+	}
+	return 0, fmt.Errorf("Requested player '%s' is missing", player)
+}
+
+func (e *EventLogPlayerStorage) DeletePlayer(player string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.append(event{Type: eventDelete, Name: player})
+}
+
+func (e *EventLogPlayerStorage) RenamePlayer(from, to string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.append(event{Type: eventRename, Name: from, NewName: to})
+}
+
+func (e *EventLogPlayerStorage) RecordGame(game leaguedb.GameRecord) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.append(event{Type: eventGame, Game: &game})
+}
+
+// StartSession logs the session's start; its ID is given when the log is
+// applied, so every process numbers sessions the same way.
+func (e *EventLogPlayerStorage) StartSession(at time.Time) (leaguedb.Session, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.append(event{Type: eventSession, Session: &leaguedb.Session{StartedAt: at}}); err != nil {
+		return leaguedb.Session{}, err
+	}
+	return e.Doc.Sessions[len(e.Doc.Sessions)-1], nil
+}
+
+func (e *EventLogPlayerStorage) AddLedgerEntry(sessionID string, entry leaguedb.LedgerEntry) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.append(event{Type: eventLedger, Name: sessionID, Entry: &entry})
+}
+
+func (e *EventLogPlayerStorage) GetSessions() ([]leaguedb.Session, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.loadShared(); err != nil {
+		return nil, err
+	}
+	return e.Doc.Sessions, nil
+}
+
+func (e *EventLogPlayerStorage) GetGames() ([]leaguedb.GameRecord, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.loadShared(); err != nil {
+		return nil, err
+	}
+	return e.Doc.Games, nil
+}
@@ -0,0 +1,275 @@
+package fss
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
+)
+
+func TestEventLogStorage(t *testing.T) {
+	t.Run("rebuilds league from the log", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, `{"Seq":1,"Type":"win","Name":"Cleo"}
+{"Seq":2,"Type":"win","Name":"Chris"}
+{"Seq":3,"Type":"win","Name":"Chris"}
+`)
+		defer cleanLog()
+
+		store, err := NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetLeagueTable()
+		tutils.AssertNoError(t, err)
+
+		want := leaguedb.League{
+			{Name: "Chris", Wins: 2},
+			{Name: "Cleo", Wins: 1},
+		}
+		tutils.AssertLeague(t, got, want)
+	})
+
+	t.Run("games count their win", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, `{"Seq":1,"Type":"win","Name":"Cleo"}
+`)
+		defer cleanLog()
+
+		store, err := NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+		tutils.AssertNoError(t, store.RecordGame(leaguedb.GameRecord{Players: 2, Winner: "Cleo"}))
+
+		store, err = NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+		got, err := store.GetPlayerScore("Cleo")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 2)
+	})
+
+	t.Run("appends one line per win", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+
+		store, err := NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		tutils.AssertNoError(t, store.PostPlayerScore("Pepper"))
+		tutils.AssertNoError(t, store.PostPlayerScore("Pepper"))
+
+		got := readFile(t, log)
+		want := `{"Seq":1,"Type":"win","Name":"Pepper"}
+{"Seq":2,"Type":"win","Name":"Pepper"}
+`
+		tutils.AssertResponseBody(t, got, want)
+	})
+
+	t.Run("wins survive a restart", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+
+		store, err := NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+		tutils.AssertNoError(t, store.PostPlayerScore("Pepper"))
+
+		store, err = NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetPlayerScore("Pepper")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 1)
+	})
+
+	t.Run("compacts into a snapshot", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+		snapshotPath := log.Name() + snapshotSuffix
+		defer os.Remove(snapshotPath)
+		defer os.Remove(snapshotPath + backupSuffix)
+
+		store, err := NewEventLogPlayerStorage(log, snapshotPath, 2)
+		tutils.AssertNoError(t, err)
+
+		tutils.AssertNoError(t, store.PostPlayerScore("Cleo"))
+		tutils.AssertNoError(t, store.PostPlayerScore("Chris"))
+		tutils.AssertNoError(t, store.PostPlayerScore("Chris"))
+
+		got := readFile(t, log)
+		want := `{"Seq":3,"Type":"win","Name":"Chris"}
+`
+		tutils.AssertResponseBody(t, got, want)
+
+		store, err = NewEventLogPlayerStorage(log, snapshotPath, 2)
+		tutils.AssertNoError(t, err)
+
+		league, err := store.GetLeagueTable()
+		tutils.AssertNoError(t, err)
+		tutils.AssertLeague(t, league, leaguedb.League{
+			{Name: "Chris", Wins: 2},
+			{Name: "Cleo", Wins: 1},
+		})
+	})
+
+	t.Run("records events when the snapshot can't be written", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+		snapshotPath := filepath.Join(t.TempDir(), "missing", "league"+snapshotSuffix)
+
+		store, err := NewEventLogPlayerStorage(log, snapshotPath, 1)
+		tutils.AssertNoError(t, err)
+
+		tutils.AssertNoError(t, store.PostPlayerScore("Cleo"))
+		tutils.AssertNoError(t, store.PostPlayerScore("Cleo"))
+
+		store, err = NewEventLogPlayerStorage(log, snapshotPath, 1)
+		tutils.AssertNoError(t, err)
+		got, err := store.GetPlayerScore("Cleo")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 2)
+	})
+
+	t.Run("retries a failed compaction on the next event", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+		dir := filepath.Join(t.TempDir(), "snapshots")
+		snapshotPath := filepath.Join(dir, "league"+snapshotSuffix)
+
+		store, err := NewEventLogPlayerStorage(log, snapshotPath, 1)
+		tutils.AssertNoError(t, err)
+		tutils.AssertNoError(t, store.PostPlayerScore("Cleo"))
+
+		tutils.AssertNoError(t, os.Mkdir(dir, 0777))
+		tutils.AssertNoError(t, store.PostPlayerScore("Chris"))
+
+		tutils.AssertResponseBody(t, readFile(t, log), "")
+		store, err = NewEventLogPlayerStorage(log, snapshotPath, 1)
+		tutils.AssertNoError(t, err)
+		league, err := store.GetLeagueTable()
+		tutils.AssertNoError(t, err)
+		tutils.AssertLeague(t, league, leaguedb.League{
+			{Name: "Cleo", Wins: 1},
+			{Name: "Chris", Wins: 1},
+		})
+	})
+
+	t.Run("skips events already in the snapshot", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, `{"Seq":1,"Type":"win","Name":"Cleo"}
+{"Seq":2,"Type":"win","Name":"Chris"}
+{"Seq":3,"Type":"win","Name":"Chris"}
+`)
+		defer cleanLog()
+		snapshotPath := log.Name() + snapshotSuffix
+		defer os.Remove(snapshotPath)
+
+		err := os.WriteFile(snapshotPath, []byte(`{"Seq":1,"League":[{"Name":"Cleo","Wins":1}]}`), 0666)
+		tutils.AssertNoError(t, err)
+
+		store, err := NewEventLogPlayerStorage(log, snapshotPath, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetLeagueTable()
+		tutils.AssertNoError(t, err)
+		tutils.AssertLeague(t, got, leaguedb.League{
+			{Name: "Chris", Wins: 2},
+			{Name: "Cleo", Wins: 1},
+		})
+	})
+
+	t.Run("replays deletes and renames", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+
+		store, err := NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		tutils.AssertNoError(t, store.PostPlayerScore("Cleo"))
+		tutils.AssertNoError(t, store.PostPlayerScore("Chris"))
+		tutils.AssertNoError(t, store.DeletePlayer("Cleo"))
+		tutils.AssertNoError(t, store.RenamePlayer("Chris", "Christopher"))
+
+		store, err = NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetLeagueTable()
+		tutils.AssertNoError(t, err)
+		tutils.AssertLeague(t, got, leaguedb.League{{Name: "Christopher", Wins: 1}})
+	})
+
+	t.Run("replays games", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+
+		store, err := NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		game := leaguedb.GameRecord{Players: 4, LastBlind: 200, Winner: "Cleo"}
+		tutils.AssertNoError(t, store.RecordGame(game))
+
+		store, err = NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetGames()
+		tutils.AssertNoError(t, err)
+		tutils.AssertGames(t, got, []leaguedb.GameRecord{game})
+	})
+
+	t.Run("does not log failed changes", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+
+		store, err := NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+
+		err = store.DeletePlayer("Cleo")
+		if !errors.Is(err, leaguedb.ErrPlayerNotFound) {
+			t.Errorf("got %v, want %v", err, leaguedb.ErrPlayerNotFound)
+		}
+		tutils.AssertResponseBody(t, readFile(t, log), "")
+	})
+
+	t.Run("concurrent stores don't lose wins", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, "")
+		defer cleanLog()
+		snapshotPath := log.Name() + snapshotSuffix
+		defer os.Remove(snapshotPath)
+		defer os.Remove(snapshotPath + backupSuffix)
+
+		first, err := NewEventLogPlayerStorage(log, snapshotPath, 7)
+		tutils.AssertNoError(t, err)
+		second, closeSecond, err := EventLogStorageFromFile(log.Name())
+		tutils.AssertNoError(t, err)
+		defer closeSecond()
+		second.compactEvery = 7
+
+		recordWinsConcurrently(t, 20, first, second)
+
+		got, err := second.GetPlayerScore("Pepper")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 40)
+	})
+
+	t.Run("drops a torn last line", func(t *testing.T) {
+		log, cleanLog := CreateTempFile(t, `{"Seq":1,"Type":"win","Name":"Cleo"}
+{"Seq":2,"Type":"wi`)
+		defer cleanLog()
+
+		store, err := NewEventLogPlayerStorage(log, log.Name()+snapshotSuffix, DefaultCompactEvery)
+		tutils.AssertNoError(t, err)
+		tutils.AssertNoError(t, store.PostPlayerScore("Chris"))
+
+		got := readFile(t, log)
+		want := `{"Seq":1,"Type":"win","Name":"Cleo"}
+{"Seq":2,"Type":"win","Name":"Chris"}
+`
+		tutils.AssertResponseBody(t, got, want)
+	})
+}
+
+func readFile(t testing.TB, file *os.File) string {
+	t.Helper()
+	file.Seek(0, io.SeekStart)
+	data, err := io.ReadAll(file)
+	tutils.AssertNoError(t, err)
+	return string(data)
+}
@@ -1,23 +1,28 @@
 package fss
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 )
 
+// FileSystemPlayerStorage keeps the whole league in one JSON file. Every read and
+// write reloads the file under an advisory lock, so several processes can share
+// it without overwriting each other's changes.
 type FileSystemPlayerStorage struct {
-	mu     sync.Mutex
-	Db     *json.Encoder
-	League leaguedb.League
+	mu   sync.Mutex
+	path string
+	Db   *json.Encoder
+	Doc  leaguedb.Document
 }
 
 func FileSystemStorageFromFile(path string) (*FileSystemPlayerStorage, func(), error) {
@@ -37,70 +42,243 @@ func FileSystemStorageFromFile(path string) (*FileSystemPlayerStorage, func(), e
 	return store, close, nil
 }
 
-func initPlayersDBFile(db *os.File) error {
-	stat, err := db.Stat()
-	if err != nil {
-		return fmt.Errorf("problem getting file info from file %s, %v", db.Name(), err)
-	}
+const (
+	FileMode = "file"
+	LogMode  = "log"
+
+	DefaultFileName = "game.db.json"
+	DefaultLogName  = "game.db.jsonl"
+
+	corruptSuffix = ".corrupt"
+	lockSuffix    = ".lock"
+)
 
-	if stat.Size() == 0 {
-		_, err = db.Write([]byte("[]"))
+// OpenStorage opens the league at path using either the rewrite-on-save file
+// storage or the append-only event log storage.
+func OpenStorage(mode, path string) (leaguedb.PlayersStorage, func(), error) {
+	switch mode {
+	case FileMode:
+		store, close, err := FileSystemStorageFromFile(path)
 		if err != nil {
-			log.Fatalf("Problem writing to %s %v", db.Name(), err)
+			return nil, nil, err
 		}
-		db.Seek(0, io.SeekStart)
+		return store, close, nil
+	case LogMode:
+		store, close, err := EventLogStorageFromFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage mode %q", mode)
 	}
-	return nil
 }
 
-func NewFSPlayerStorage(db *os.File) (*FileSystemPlayerStorage, error) {
-	db.Seek(0, io.SeekStart)
+// DefaultPath is where the league is kept in the storage mode.
+func DefaultPath(mode string) string {
+	if mode == LogMode {
+		return DefaultLogName
+	}
+	return DefaultFileName
+}
 
-	err := initPlayersDBFile(db)
+func NewFSPlayerStorage(db *os.File) (*FileSystemPlayerStorage, error) {
+	unlock, err := lockFile(db.Name()+lockSuffix, true)
 	if err != nil {
-		return nil, fmt.Errorf("problem initialising player db file, %v", err)
+		return nil, fmt.Errorf("problem locking %s, %v", db.Name(), err)
 	}
+	defer unlock()
+
+	db.Seek(0, io.SeekStart)
 
-	league, err := leaguedb.NewLeague(db)
+	doc, err := leaguedb.NewDocument(db)
+	if errors.Is(err, leaguedb.ErrNewerVersion) {
+		return nil, fmt.Errorf("problem loading player storage from file %s, %w", db.Name(), err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("problem loading player storage from file %s, %v", db.Name(), err)
+		doc, err = recoverDocument(db)
+		if err != nil {
+			return nil, fmt.Errorf("problem loading player storage from file %s, %v", db.Name(), err)
+		}
 	}
+	os.Remove(db.Name() + tmpSuffix)
 
 	return &FileSystemPlayerStorage{
-		Db:     json.NewEncoder(&tape{file: db}),
-		League: league,
+		path: db.Name(),
+		Db:   json.NewEncoder(&tape{path: db.Name()}),
+		Doc:  doc,
 	}, nil
 }
 
+// recoverDocument is used when the main file can't be parsed. An unreadable file
+// is moved aside and the league is restored from a leftover temp file or the
+// backup, whichever parses first. A new file starts with an empty league.
+func recoverDocument(db *os.File) (leaguedb.Document, error) {
+	path := db.Name()
+
+	stat, err := db.Stat()
+	if err != nil {
+		return leaguedb.Document{}, fmt.Errorf("problem getting file info from file %s, %v", path, err)
+	}
+	if stat.Size() > 0 {
+		if err := os.Rename(path, path+corruptSuffix); err != nil {
+			return leaguedb.Document{}, fmt.Errorf("problem moving unreadable %s aside, %v", path, err)
+		}
+		log.Printf("%s is unreadable, moved it to %s", path, path+corruptSuffix)
+	}
+
+	doc := leaguedb.NewEmptyDocument()
+	for _, candidate := range []string{path + tmpSuffix, path + backupSuffix} {
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			continue
+		}
+		recovered, err := leaguedb.NewDocument(bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+		log.Printf("recovered %s from %s", path, candidate)
+		doc = recovered
+		break
+	}
+
+	if err := json.NewEncoder(&tape{path: path}).Encode(doc); err != nil {
+		return leaguedb.Document{}, fmt.Errorf("problem writing %s, %v", path, err)
+	}
+	return doc, nil
+}
+
 func (f *FileSystemPlayerStorage) PostPlayerScore(player string) error {
+	return f.update(func(doc *leaguedb.Document) error {
+		doc.Players.RecordWin(player)
+		return nil
+	})
+}
+
+func (f *FileSystemPlayerStorage) DeletePlayer(player string) error {
+	return f.update(func(doc *leaguedb.Document) error {
+		return doc.Players.Delete(player)
+	})
+}
+
+func (f *FileSystemPlayerStorage) RenamePlayer(from, to string) error {
+	return f.update(func(doc *leaguedb.Document) error {
+		return doc.Players.Rename(from, to)
+	})
+}
+
+func (f *FileSystemPlayerStorage) RecordGame(game leaguedb.GameRecord) error {
+	return f.update(func(doc *leaguedb.Document) error {
+		doc.AddGame(game)
+		return nil
+	})
+}
+
+func (f *FileSystemPlayerStorage) GetGames() ([]leaguedb.GameRecord, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	if p := f.League.Find(player); p != nil {
-		p.Wins++
-	} else {
-		f.League = append(f.League, leaguedb.Player{Name: player, Wins: 1})
+	if err := f.reloadShared(); err != nil {
+		return nil, err
 	}
-	// TODO: fix the issue related to deleting players (Though it's not implemented yet).
-	// If file length will decrease compare to initial state it will break everything
-	f.Db.Encode(f.League)
-	return nil
+	return f.Doc.Games, nil
 }
 
-func (f *FileSystemPlayerStorage) GetLeagueTable() (leaguedb.League, error) {
-	sort.Slice(f.League, func(i, j int) bool {
-		return f.League[i].Wins > f.League[j].Wins
+func (f *FileSystemPlayerStorage) StartSession(at time.Time) (leaguedb.Session, error) {
+	var session leaguedb.Session
+	err := f.update(func(doc *leaguedb.Document) error {
+		session = doc.StartSession(at)
+		return nil
+	})
+	return session, err
+}
+
+func (f *FileSystemPlayerStorage) AddLedgerEntry(sessionID string, entry leaguedb.LedgerEntry) error {
+	return f.update(func(doc *leaguedb.Document) error {
+		return doc.AddLedgerEntry(sessionID, entry)
 	})
-	return f.League, nil
+}
+
+func (f *FileSystemPlayerStorage) GetSessions() ([]leaguedb.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reloadShared(); err != nil {
+		return nil, err
+	}
+	return f.Doc.Sessions, nil
+}
+
+func (f *FileSystemPlayerStorage) GetLeagueTable() (leaguedb.League, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reloadShared(); err != nil {
+		return nil, err
+	}
+	f.Doc.Players.SortByWins()
+	return f.Doc.Players, nil
 }
 
 func (f *FileSystemPlayerStorage) GetPlayerScore(player string) (int, error) {
-	if p := f.League.Find(player); p != nil {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reloadShared(); err != nil {
+		return 0, err
+	}
+	if p := f.Doc.Players.Find(player); p != nil {
 		return p.Wins, nil
 	}
 	return 0, errors.New(fmt.Sprintf("Requested player '%s' is missing", player))
 }
 
+// update reloads the league while holding the exclusive file lock, applies the
+// change and writes the result before the lock is released.
+func (f *FileSystemPlayerStorage) update(change func(*leaguedb.Document) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	unlock, err := lockFile(f.path+lockSuffix, true)
+	if err != nil {
+		return fmt.Errorf("problem locking %s, %v", f.path, err)
+	}
+	defer unlock()
+
+	if err := f.reload(); err != nil {
+		return err
+	}
+	if err := change(&f.Doc); err != nil {
+		return err
+	}
+	return f.Db.Encode(f.Doc)
+}
+
+func (f *FileSystemPlayerStorage) reloadShared() error {
+	unlock, err := lockFile(f.path+lockSuffix, false)
+	if err != nil {
+		return fmt.Errorf("problem locking %s, %v", f.path, err)
+	}
+	defer unlock()
+
+	return f.reload()
+}
+
+func (f *FileSystemPlayerStorage) reload() error {
+	db, err := os.Open(f.path)
+	if err != nil {
+		return fmt.Errorf("problem opening %s %v", f.path, err)
+	}
+	defer db.Close()
+
+	doc, err := leaguedb.NewDocument(db)
+	if err != nil {
+		return fmt.Errorf("problem reloading %s, %w", f.path, err)
+	}
+	f.Doc = doc
+	return nil
+}
+
 func CreateTempFile(t testing.TB, initalData string) (*os.File, func()) {
 	t.Helper()
 
@@ -112,7 +290,9 @@ func CreateTempFile(t testing.TB, initalData string) (*os.File, func()) {
 	tmpfile.Write([]byte(initalData))
 	removeFile := func() {
 		tmpfile.Close()
-		os.Remove(tmpfile.Name())
+		for _, suffix := range []string{"", tmpSuffix, backupSuffix, corruptSuffix, lockSuffix} {
+			os.Remove(tmpfile.Name() + suffix)
+		}
 	}
 
 	return tmpfile, removeFile
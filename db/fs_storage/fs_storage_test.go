@@ -1,7 +1,13 @@
 package fss
 
 import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
@@ -117,4 +123,335 @@ func TestFileSystemStorage(t *testing.T) {
 		tutils.AssertNoError(t, err)
 		tutils.AssertLeague(t, got, want)
 	})
+	t.Run("delete player", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `[
+			{"Name": "Cleo", "Wins": 10},
+			{"Name": "Chris", "Wins": 33}]`)
+		defer cleanDatabase()
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+
+		tutils.AssertNoError(t, store.DeletePlayer("Chris"))
+
+		reopened, closeStore, err := FileSystemStorageFromFile(db.Name())
+		tutils.AssertNoError(t, err)
+		defer closeStore()
+
+		got, err := reopened.GetLeagueTable()
+		tutils.AssertNoError(t, err)
+		tutils.AssertLeague(t, got, leaguedb.League{{Name: "Cleo", Wins: 10}})
+	})
+
+	t.Run("delete missing player", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+
+		err = store.DeletePlayer("Chris")
+		if !errors.Is(err, leaguedb.ErrPlayerNotFound) {
+			t.Errorf("got %v, want %v", err, leaguedb.ErrPlayerNotFound)
+		}
+	})
+
+	t.Run("rename player", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `[
+			{"Name": "Cleo", "Wins": 10},
+			{"Name": "Chris", "Wins": 33}]`)
+		defer cleanDatabase()
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+
+		tutils.AssertNoError(t, store.RenamePlayer("Chris", "Christopher"))
+
+		reopened, closeStore, err := FileSystemStorageFromFile(db.Name())
+		tutils.AssertNoError(t, err)
+		defer closeStore()
+
+		got, err := reopened.GetPlayerScore("Christopher")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 33)
+	})
+
+	t.Run("rename to a taken name", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `[
+			{"Name": "Cleo", "Wins": 10},
+			{"Name": "Chris", "Wins": 33}]`)
+		defer cleanDatabase()
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+
+		err = store.RenamePlayer("Chris", "Cleo")
+		if !errors.Is(err, leaguedb.ErrPlayerExists) {
+			t.Errorf("got %v, want %v", err, leaguedb.ErrPlayerExists)
+		}
+	})
+	t.Run("writes survive a restart", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, "")
+		defer cleanDatabase()
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+		tutils.AssertNoError(t, store.PostPlayerScore("Pepper"))
+
+		reopened, closeStore, err := FileSystemStorageFromFile(db.Name())
+		tutils.AssertNoError(t, err)
+		defer closeStore()
+
+		got, err := reopened.GetPlayerScore("Pepper")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 1)
+	})
+}
+
+func TestFileSystemStorageGames(t *testing.T) {
+	db, cleanDatabase := CreateTempFile(t, "")
+	defer cleanDatabase()
+
+	store, err := NewFSPlayerStorage(db)
+	tutils.AssertNoError(t, err)
+
+	startedAt := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	game := leaguedb.GameRecord{
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(90 * time.Minute),
+		Players:    6,
+		LastBlind:  800,
+		Winner:     "Chris",
+	}
+	tutils.AssertNoError(t, store.RecordGame(game))
+
+	reopened, closeStore, err := FileSystemStorageFromFile(db.Name())
+	tutils.AssertNoError(t, err)
+	defer closeStore()
+
+	got, err := reopened.GetGames()
+	tutils.AssertNoError(t, err)
+	tutils.AssertGames(t, got, []leaguedb.GameRecord{game})
+
+	t.Run("counts the win and rates the winner", func(t *testing.T) {
+		league, err := reopened.GetLeagueTable()
+		tutils.AssertNoError(t, err)
+		tutils.AssertLeague(t, league, leaguedb.League{{Name: "Chris", Wins: 1, Rating: 1516}})
+	})
+}
+
+func TestStorageLedger(t *testing.T) {
+	for _, mode := range []string{FileMode, LogMode} {
+		t.Run(mode, func(t *testing.T) {
+			db, cleanDatabase := CreateTempFile(t, "")
+			defer cleanDatabase()
+			defer os.Remove(db.Name() + snapshotSuffix)
+
+			store, closeStore, err := OpenStorage(mode, db.Name())
+			tutils.AssertNoError(t, err)
+			ledger := store.(leaguedb.LedgerStorage)
+
+			startedAt := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+			session, err := ledger.StartSession(startedAt)
+			tutils.AssertNoError(t, err)
+			tutils.AssertNoError(t, ledger.AddLedgerEntry(session.ID, leaguedb.LedgerEntry{Player: "Ruth", Kind: leaguedb.BuyInEntry, Amount: 50}))
+			if err := ledger.AddLedgerEntry("7", leaguedb.LedgerEntry{Player: "Ruth", Kind: leaguedb.BuyInEntry, Amount: 50}); !errors.Is(err, leaguedb.ErrSessionNotFound) {
+				t.Errorf("got %v, want ErrSessionNotFound", err)
+			}
+			closeStore()
+
+			reopened, closeStore, err := OpenStorage(mode, db.Name())
+			tutils.AssertNoError(t, err)
+			defer closeStore()
+
+			got, err := reopened.(leaguedb.LedgerStorage).GetSessions()
+			tutils.AssertNoError(t, err)
+			want := []leaguedb.Session{{
+				ID:        "1",
+				StartedAt: startedAt,
+				Entries:   []leaguedb.LedgerEntry{{Player: "Ruth", Kind: leaguedb.BuyInEntry, Amount: 50}},
+			}}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got sessions %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFileSystemStorageSchema(t *testing.T) {
+	t.Run("upgrades a bare league array on the next write", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+		tutils.AssertNoError(t, store.PostPlayerScore("Cleo"))
+
+		assertStoredDocument(t, db.Name(), leaguedb.League{{Name: "Cleo", Wins: 11}})
+	})
+
+	t.Run("refuses a file from a newer version", func(t *testing.T) {
+		newer := `{"Version": 99, "Players": []}`
+		db, cleanDatabase := CreateTempFile(t, newer)
+		defer cleanDatabase()
+
+		_, err := NewFSPlayerStorage(db)
+		if !errors.Is(err, leaguedb.ErrNewerVersion) {
+			t.Errorf("got %v, want %v", err, leaguedb.ErrNewerVersion)
+		}
+		assertFileContains(t, db.Name(), newer)
+	})
+}
+
+func TestFileSystemStorageSharedFile(t *testing.T) {
+	t.Run("sees wins recorded by another store", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `[{"Name": "Chris", "Wins": 33}]`)
+		defer cleanDatabase()
+
+		first, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+		second, closeSecond, err := FileSystemStorageFromFile(db.Name())
+		tutils.AssertNoError(t, err)
+		defer closeSecond()
+
+		tutils.AssertNoError(t, first.PostPlayerScore("Chris"))
+		tutils.AssertNoError(t, second.PostPlayerScore("Chris"))
+
+		got, err := first.GetPlayerScore("Chris")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 35)
+	})
+
+	t.Run("concurrent stores don't lose wins", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, "")
+		defer cleanDatabase()
+
+		first, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+		second, closeSecond, err := FileSystemStorageFromFile(db.Name())
+		tutils.AssertNoError(t, err)
+		defer closeSecond()
+
+		recordWinsConcurrently(t, 20, first, second)
+
+		got, err := first.GetPlayerScore("Pepper")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 40)
+	})
+}
+
+func TestFileSystemStorageRecovery(t *testing.T) {
+	t.Run("recovers from a leftover temp file", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `[{"Name": "Cleo", "Wi`)
+		defer cleanDatabase()
+		writeSibling(t, db, tmpSuffix, `[{"Name": "Cleo", "Wins": 11}]`)
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetPlayerScore("Cleo")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 11)
+		assertStoredDocument(t, db.Name(), leaguedb.League{{Name: "Cleo", Wins: 11}})
+		assertNoFile(t, db.Name()+tmpSuffix)
+	})
+
+	t.Run("recovers from the backup", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `not json`)
+		defer cleanDatabase()
+		writeSibling(t, db, backupSuffix, `[{"Name": "Cleo", "Wins": 10}]`)
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetPlayerScore("Cleo")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 10)
+		assertFileContains(t, db.Name()+corruptSuffix, "not json")
+	})
+
+	t.Run("starts empty when nothing can be recovered", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `not json`)
+		defer cleanDatabase()
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetLeagueTable()
+		tutils.AssertNoError(t, err)
+		tutils.AssertLeague(t, got, leaguedb.League{})
+		assertFileContains(t, db.Name()+corruptSuffix, "not json")
+	})
+
+	t.Run("ignores a stale temp file when the main file is fine", func(t *testing.T) {
+		db, cleanDatabase := CreateTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+		writeSibling(t, db, tmpSuffix, `[{"Name": "Cleo", "Wi`)
+
+		store, err := NewFSPlayerStorage(db)
+		tutils.AssertNoError(t, err)
+
+		got, err := store.GetPlayerScore("Cleo")
+		tutils.AssertNoError(t, err)
+		tutils.AssertPlayerScore(t, got, 10)
+		assertNoFile(t, db.Name()+tmpSuffix)
+	})
+}
+
+func recordWinsConcurrently(t testing.TB, wins int, stores ...leaguedb.PlayersStorage) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	for _, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range wins {
+				if err := store.PostPlayerScore("Pepper"); err != nil {
+					t.Errorf("didn't expect an error but got one, %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func writeSibling(t testing.TB, db *os.File, suffix, data string) {
+	t.Helper()
+	if err := os.WriteFile(db.Name()+suffix, []byte(data), 0666); err != nil {
+		t.Fatalf("could not write %s: %v", db.Name()+suffix, err)
+	}
+}
+
+// assertStoredDocument reads the file without migrations, so it fails unless the
+// file was written in the current layout.
+func assertStoredDocument(t testing.TB, path string, want leaguedb.League) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	tutils.AssertNoError(t, err)
+
+	var doc leaguedb.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("could not parse %s: %v", path, err)
+	}
+	if doc.Version != leaguedb.CurrentVersion {
+		t.Errorf("got version %d in %s, want %d", doc.Version, path, leaguedb.CurrentVersion)
+	}
+	tutils.AssertLeague(t, doc.Players, want)
+}
+
+func assertFileContains(t testing.TB, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	tutils.AssertNoError(t, err)
+	if string(got) != want {
+		t.Errorf("got %q in %s, want %q", got, path, want)
+	}
+}
+
+func assertNoFile(t testing.TB, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected %s to be gone, got %v", path, err)
+	}
 }
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package fss
+
+// lockFile is a no-op where flock isn't available, so sharing a league file
+// between processes is only safe on unix systems.
+func lockFile(path string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
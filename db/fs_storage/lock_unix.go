@@ -0,0 +1,31 @@
+//go:build unix
+
+package fss
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an advisory flock on path, creating it if needed. It blocks
+// until the lock is granted and returns a func that releases it.
+func lockFile(path string, exclusive bool) (func(), error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(file.Fd()), how); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
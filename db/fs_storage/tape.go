@@ -1,16 +1,54 @@
 package fss
 
 import (
-	"io"
 	"os"
+	"path/filepath"
 )
 
+const (
+	tmpSuffix    = ".tmp"
+	backupSuffix = ".bak"
+)
+
+// tape replaces the whole file on every write. The data goes to a temp file that
+// is synced and renamed over the original, so a crash leaves either the old or
+// the new contents but never a half written file. The previous version is kept
+// as a hard link in the backup file.
 type tape struct {
-	file *os.File
+	path string
 }
 
 func (t *tape) Write(p []byte) (n int, err error) {
-	t.file.Truncate(0)
-	t.file.Seek(0, io.SeekStart)
-	return t.file.Write(p)
+	tmp, err := os.OpenFile(t.path+tmpSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err = tmp.Write(p)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	os.Remove(t.path + backupSuffix)
+	os.Link(t.path, t.path+backupSuffix)
+
+	if err := os.Rename(t.path+tmpSuffix, t.path); err != nil {
+		return 0, err
+	}
+	return n, syncDir(filepath.Dir(t.path))
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
 }
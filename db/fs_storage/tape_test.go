@@ -1,7 +1,8 @@
 package fss
 
 import (
-	"io"
+	"errors"
+	"os"
 	"testing"
 )
 
@@ -9,11 +10,10 @@ func TestTape(t *testing.T) {
 	file, clean := CreateTempFile(t, "12345")
 	defer clean()
 
-	tape := &tape{file: file}
+	tape := &tape{path: file.Name()}
 
 	tape.Write([]byte("abc"))
-	file.Seek(0, io.SeekStart)
-	newFileContents, _ := io.ReadAll(file)
+	newFileContents, _ := os.ReadFile(file.Name())
 
 	got := string(newFileContents)
 	want := "abc"
@@ -21,4 +21,17 @@ func TestTape(t *testing.T) {
 	if got != want {
 		t.Errorf("got %q want %q", got, want)
 	}
+
+	t.Run("keeps the previous version as a backup", func(t *testing.T) {
+		backup, _ := os.ReadFile(file.Name() + backupSuffix)
+		if string(backup) != "12345" {
+			t.Errorf("got backup %q want %q", backup, "12345")
+		}
+	})
+
+	t.Run("leaves no temp file behind", func(t *testing.T) {
+		if _, err := os.Stat(file.Name() + tmpSuffix); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected temp file to be renamed, got %v", err)
+		}
+	})
 }
@@ -0,0 +1,90 @@
+package leaguedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kinds of GameEvent.
+const (
+	EliminationEvent = "elimination"
+	RebuyEvent       = "rebuy"
+	AddOnEvent       = "addon"
+)
+
+// GameRecord is a finished game.
+type GameRecord struct {
+	ID             string `json:",omitempty"` // while it ran
+	StartedAt      time.Time
+	FinishedAt     time.Time
+	Players        int
+	LastBlind      int
+	Winner         string
+	FinishingOrder []string        `json:",omitempty"` // from the winner down; older records only have a Winner
+	Participants   []string        `json:",omitempty"` // the registered players, for games started with names
+	Events         []GameEvent     `json:",omitempty"` // eliminations, rebuys and add-ons
+	BuyIn          int             `json:",omitempty"`
+	PrizePool      int             `json:",omitempty"`
+	Payouts        []Payout        `json:",omitempty"`
+	Hands          json.RawMessage `json:",omitempty"` // hand histories, as the poker package writes them
+}
+
+// GameEvent is something that happened to a player during a game.
+type GameEvent struct {
+	At     time.Time
+	Kind   string
+	Player string
+}
+
+func (e GameEvent) String() string {
+	switch e.Kind {
+	case EliminationEvent:
+		return fmt.Sprintf("%s is out", e.Player)
+	case RebuyEvent:
+		return fmt.Sprintf("%s rebought", e.Player)
+	case AddOnEvent:
+		return fmt.Sprintf("%s took an add-on", e.Player)
+	default:
+		return fmt.Sprintf("%s: %s", e.Player, e.Kind)
+	}
+}
+
+func (g GameRecord) Duration() time.Duration {
+	return g.FinishedAt.Sub(g.StartedAt)
+}
+
+// AddGame stores the record, counts the winner's win and updates the ratings
+// and winnings of everyone who took part.
+func (d *Document) AddGame(game GameRecord) {
+	if game.Winner != "" {
+		d.Players.RecordWin(game.Winner)
+	}
+	d.Games = append(d.Games, game)
+	d.Players.RateGame(game.Placings(), game.Unplaced(), game.Players)
+	d.Players.AddWinnings(game.Payouts)
+}
+
+// Unplaced lists the participants who aren't in the known finishing order.
+func (g GameRecord) Unplaced() []string {
+	var unplaced []string
+	for _, name := range g.Participants {
+		if !slices.ContainsFunc(g.Placings(), func(placed string) bool { return strings.EqualFold(placed, name) }) {
+			unplaced = append(unplaced, name)
+		}
+	}
+	return unplaced
+}
+
+// Placings lists the known finishing order from first place down.
+func (g GameRecord) Placings() []string {
+	if len(g.FinishingOrder) > 0 {
+		return g.FinishingOrder
+	}
+	if g.Winner == "" {
+		return nil
+	}
+	return []string{g.Winner}
+}
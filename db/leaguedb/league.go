@@ -1,31 +1,39 @@
 package leaguedb
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
+)
+
+var (
+	ErrPlayerNotFound = errors.New("player not found")
+	ErrPlayerExists   = errors.New("player already exists")
 )
 
 type PlayersStorage interface {
 	GetPlayerScore(string) (int, error)
 	PostPlayerScore(string) error
 	GetLeagueTable() (League, error)
+	DeletePlayer(string) error
+	RenamePlayer(string, string) error
+	RecordGame(GameRecord) error
+	GetGames() ([]GameRecord, error)
 }
 
 type Player struct {
-	Name string
-	Wins int
+	Name     string
+	Wins     int
+	Rating   float64 `json:",omitempty"`
+	Winnings int     `json:",omitempty"`
 }
 
 type League []Player
 
 func NewLeague(r io.Reader) (League, error) {
-	var leag League
-	err := json.NewDecoder(r).Decode(&leag)
-	if err != nil {
-		err = fmt.Errorf("problem parsing league, %v", err)
-	}
-	return leag, err
+	doc, err := NewDocument(r)
+	return doc.Players, err
 }
 
 func (l League) Find(name string) *Player {
@@ -36,3 +44,39 @@ func (l League) Find(name string) *Player {
 	}
 	return nil
 }
+
+func (l *League) RecordWin(name string) {
+	if p := l.Find(name); p != nil {
+		p.Wins++
+		return
+	}
+	*l = append(*l, Player{Name: name, Wins: 1})
+}
+
+func (l League) SortByWins() {
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Wins > l[j].Wins
+	})
+}
+
+func (l *League) Delete(name string) error {
+	for i, p := range *l {
+		if p.Name == name {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("can't delete %q, %w", name, ErrPlayerNotFound)
+}
+
+func (l League) Rename(from, to string) error {
+	p := l.Find(from)
+	if p == nil {
+		return fmt.Errorf("can't rename %q, %w", from, ErrPlayerNotFound)
+	}
+	if from != to && l.Find(to) != nil {
+		return fmt.Errorf("can't rename %q to %q, %w", from, to, ErrPlayerExists)
+	}
+	p.Name = to
+	return nil
+}
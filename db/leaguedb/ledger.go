@@ -0,0 +1,254 @@
+package leaguedb
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of LedgerEntry.
+const (
+	BuyInEntry   = "buyin"
+	CashOutEntry = "cashout"
+)
+
+// maxExactSettle is the most players whose transfers are minimised exactly. The
+// search is exponential, so bigger sessions are settled greedily.
+const maxExactSettle = 16
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrBadLedgerEntry  = errors.New("bad ledger entry")
+)
+
+// LedgerStorage is implemented by storages that keep the home game ledger.
+type LedgerStorage interface {
+	StartSession(at time.Time) (Session, error)
+	AddLedgerEntry(sessionID string, entry LedgerEntry) error
+	GetSessions() ([]Session, error)
+}
+
+// Session is one night of a home cash game.
+type Session struct {
+	ID        string
+	StartedAt time.Time
+	Entries   []LedgerEntry
+}
+
+// LedgerEntry is money a player put in with a buy-in or took out with a
+// cash-out.
+type LedgerEntry struct {
+	Player string
+	Kind   string
+	Amount int
+}
+
+// Balance is where a player stands in a session. Net is what they are owed,
+// negative when they owe money.
+type Balance struct {
+	Player   string
+	BuyIns   int
+	CashOuts int
+	Net      int
+}
+
+type Transfer struct {
+	From   string
+	To     string
+	Amount int
+}
+
+// Settlement is who owes whom at the end of a session. Unaccounted is money
+// bought in but not cashed out yet, or cashed out without being bought in when
+// negative; a session only settles once it is zero.
+type Settlement struct {
+	Balances    []Balance
+	Transfers   []Transfer `json:",omitempty"`
+	Unaccounted int
+}
+
+// StartSession adds a new session to the ledger.
+func (d *Document) StartSession(at time.Time) Session {
+	session := Session{ID: strconv.Itoa(len(d.Sessions) + 1), StartedAt: at}
+	d.Sessions = append(d.Sessions, session)
+	return session
+}
+
+func (d *Document) AddLedgerEntry(sessionID string, entry LedgerEntry) error {
+	if err := entry.validate(); err != nil {
+		return err
+	}
+	for i := range d.Sessions {
+		if d.Sessions[i].ID == sessionID {
+			d.Sessions[i].Entries = append(d.Sessions[i].Entries, entry)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q", ErrSessionNotFound, sessionID)
+}
+
+func (e LedgerEntry) validate() error {
+	if strings.TrimSpace(e.Player) == "" {
+		return fmt.Errorf("%w, no player", ErrBadLedgerEntry)
+	}
+	if e.Kind != BuyInEntry && e.Kind != CashOutEntry {
+		return fmt.Errorf("%w, unknown kind %q, use %q or %q", ErrBadLedgerEntry, e.Kind, BuyInEntry, CashOutEntry)
+	}
+	if e.Amount <= 0 {
+		return fmt.Errorf("%w, amount %d isn't positive", ErrBadLedgerEntry, e.Amount)
+	}
+	return nil
+}
+
+// Balances totals the session for each player, ordered by name.
+func (s Session) Balances() []Balance {
+	byName := map[string]*Balance{}
+	var balances []*Balance
+	for _, e := range s.Entries {
+		b, ok := byName[e.Player]
+		if !ok {
+			b = &Balance{Player: e.Player}
+			byName[e.Player] = b
+			balances = append(balances, b)
+		}
+		switch e.Kind {
+		case BuyInEntry:
+			b.BuyIns += e.Amount
+		case CashOutEntry:
+			b.CashOuts += e.Amount
+		}
+		b.Net = b.CashOuts - b.BuyIns
+	}
+
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Player < balances[j].Player })
+	result := make([]Balance, len(balances))
+	for i, b := range balances {
+		result[i] = *b
+	}
+	return result
+}
+
+// Settle works out the fewest transfers that pay everyone what they are owed,
+// ordered by who pays: each group of n players whose debts cancel out settles
+// in n-1 transfers, so the more groups the better.
+func (s Session) Settle() Settlement {
+	settlement := Settlement{Balances: s.Balances()}
+
+	var open []Balance
+	for _, b := range settlement.Balances {
+		settlement.Unaccounted -= b.Net
+		if b.Net != 0 {
+			open = append(open, b)
+		}
+	}
+	if settlement.Unaccounted != 0 {
+		return settlement
+	}
+
+	for _, group := range zeroSumGroups(open) {
+		settlement.Transfers = append(settlement.Transfers, settleGroup(group)...)
+	}
+	sort.Slice(settlement.Transfers, func(i, j int) bool {
+		a, b := settlement.Transfers[i], settlement.Transfers[j]
+		return a.From < b.From || (a.From == b.From && a.To < b.To)
+	})
+	return settlement
+}
+
+// zeroSumGroups splits the balances into the most groups whose nets add up to
+// zero, by finding over every subset how many zero sum groups it splits into.
+func zeroSumGroups(balances []Balance) [][]Balance {
+	n := len(balances)
+	if n == 0 {
+		return nil
+	}
+	if n > maxExactSettle {
+		return [][]Balance{balances}
+	}
+
+	full := 1<<n - 1
+	sums := make([]int, full+1)
+	groups := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		low := mask & -mask
+		sums[mask] = sums[mask^low] + balances[bits.TrailingZeros(uint(low))].Net
+		for j := 0; j < n; j++ {
+			if mask&(1<<j) != 0 {
+				groups[mask] = max(groups[mask], groups[mask^(1<<j)])
+			}
+		}
+		if sums[mask] == 0 {
+			groups[mask]++
+		}
+	}
+
+	// Take players out in an order that keeps the most groups, then cut the
+	// order wherever the running total comes back to zero.
+	var order []int
+	for mask := full; mask != 0; {
+		for j := 0; j < n; j++ {
+			bit := 1 << j
+			if mask&bit == 0 {
+				continue
+			}
+			closes := 0
+			if sums[mask] == 0 {
+				closes = 1
+			}
+			if groups[mask^bit]+closes == groups[mask] {
+				order = append(order, j)
+				mask ^= bit
+				break
+			}
+		}
+	}
+
+	var result [][]Balance
+	var group []Balance
+	total := 0
+	for i := len(order) - 1; i >= 0; i-- {
+		b := balances[order[i]]
+		group = append(group, b)
+		total += b.Net
+		if total == 0 {
+			result = append(result, group)
+			group = nil
+		}
+	}
+	return result
+}
+
+// settleGroup has the biggest debtor pay the biggest creditor until everyone is
+// square. Every transfer squares at least one player and the last squares two.
+func settleGroup(group []Balance) []Transfer {
+	nets := map[string]int{}
+	for _, b := range group {
+		nets[b.Player] = b.Net
+	}
+
+	var transfers []Transfer
+	for {
+		debtor, creditor := "", ""
+		for _, b := range group {
+			net := nets[b.Player]
+			if net < 0 && (debtor == "" || net < nets[debtor]) {
+				debtor = b.Player
+			}
+			if net > 0 && (creditor == "" || net > nets[creditor]) {
+				creditor = b.Player
+			}
+		}
+		if debtor == "" || creditor == "" {
+			return transfers
+		}
+
+		amount := min(-nets[debtor], nets[creditor])
+		transfers = append(transfers, Transfer{From: debtor, To: creditor, Amount: amount})
+		nets[debtor] += amount
+		nets[creditor] -= amount
+	}
+}
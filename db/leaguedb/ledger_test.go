@@ -0,0 +1,126 @@
+package leaguedb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSettle(t *testing.T) {
+	session := func(entries ...LedgerEntry) Session {
+		return Session{ID: "1", Entries: entries}
+	}
+	buyIn := func(player string, amount int) LedgerEntry { return LedgerEntry{player, BuyInEntry, amount} }
+	cashOut := func(player string, amount int) LedgerEntry { return LedgerEntry{player, CashOutEntry, amount} }
+
+	t.Run("balances every player", func(t *testing.T) {
+		got := session(buyIn("Ruth", 50), buyIn("Chris", 50), buyIn("Ruth", 50), cashOut("Chris", 130), cashOut("Ruth", 20)).Balances()
+		want := []Balance{
+			{Player: "Chris", BuyIns: 50, CashOuts: 130, Net: 80},
+			{Player: "Ruth", BuyIns: 100, CashOuts: 20, Net: -80},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	cases := []struct {
+		name    string
+		session Session
+		want    []Transfer
+	}{
+		{
+			name:    "one debtor pays everyone",
+			session: session(buyIn("Ruth", 100), buyIn("Chris", 100), buyIn("Cleo", 100), cashOut("Chris", 150), cashOut("Cleo", 150)),
+			want:    []Transfer{{"Ruth", "Chris", 50}, {"Ruth", "Cleo", 50}},
+		},
+		{
+			name: "players who owe each other exactly settle between themselves",
+			session: session(
+				buyIn("Ann", 10), buyIn("Bob", 10), buyIn("Cat", 10), buyIn("Dan", 10), buyIn("Eve", 10),
+				cashOut("Ann", 16), cashOut("Bob", 6), cashOut("Cat", 8), cashOut("Dan", 15), cashOut("Eve", 5),
+			),
+			want: []Transfer{{"Bob", "Ann", 4}, {"Cat", "Ann", 2}, {"Eve", "Dan", 5}},
+		},
+		{
+			name:    "nobody owes anything",
+			session: session(buyIn("Ruth", 100), cashOut("Ruth", 100)),
+			want:    nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.session.Settle()
+			if got.Unaccounted != 0 {
+				t.Fatalf("got %d unaccounted, want 0", got.Unaccounted)
+			}
+			if !reflect.DeepEqual(got.Transfers, c.want) {
+				t.Errorf("got %v, want %v", got.Transfers, c.want)
+			}
+		})
+	}
+
+	t.Run("a session with money still on the table doesn't settle", func(t *testing.T) {
+		got := session(buyIn("Ruth", 100), buyIn("Chris", 100), cashOut("Chris", 150)).Settle()
+
+		if got.Unaccounted != 50 || got.Transfers != nil {
+			t.Errorf("got %+v, want 50 unaccounted and no transfers", got)
+		}
+	})
+
+	t.Run("transfers always settle everyone in the fewest moves", func(t *testing.T) {
+		nets := []int{6, -4, -2, 5, -5, 7, -3, -4}
+		var entries []LedgerEntry
+		for i, net := range nets {
+			player := string(rune('A' + i))
+			entries = append(entries, buyIn(player, 100), cashOut(player, 100+net))
+		}
+
+		got := session(entries...).Settle()
+
+		// {6, -4, -2}, {5, -5} and {7, -3, -4} settle in 2 + 1 + 2. Always paying
+		// the biggest creditor takes 6.
+		if len(got.Transfers) != 5 {
+			t.Errorf("got %d transfers %v, want 5", len(got.Transfers), got.Transfers)
+		}
+		owed := map[string]int{}
+		for _, tr := range got.Transfers {
+			owed[tr.From] += tr.Amount
+			owed[tr.To] -= tr.Amount
+		}
+		for _, b := range got.Balances {
+			if owed[b.Player] != -b.Net {
+				t.Errorf("%s ends up %d off", b.Player, owed[b.Player]+b.Net)
+			}
+		}
+	})
+}
+
+func TestLedgerEntries(t *testing.T) {
+	doc := NewEmptyDocument()
+	session := doc.StartSession(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+
+	t.Run("entries go to their session", func(t *testing.T) {
+		assertNoError(t, doc.AddLedgerEntry(session.ID, LedgerEntry{"Ruth", BuyInEntry, 50}))
+
+		if got := doc.Sessions[0].Entries; len(got) != 1 {
+			t.Errorf("got entries %v, want Ruth's buy-in", got)
+		}
+	})
+
+	t.Run("unknown sessions", func(t *testing.T) {
+		err := doc.AddLedgerEntry("7", LedgerEntry{"Ruth", BuyInEntry, 50})
+		if !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("got %v, want ErrSessionNotFound", err)
+		}
+	})
+
+	for _, entry := range []LedgerEntry{{"", BuyInEntry, 50}, {"Ruth", "tip", 5}, {"Ruth", CashOutEntry, 0}} {
+		t.Run("rejects "+entry.Kind, func(t *testing.T) {
+			if err := doc.AddLedgerEntry(session.ID, entry); !errors.Is(err, ErrBadLedgerEntry) {
+				t.Errorf("got %v, want ErrBadLedgerEntry", err)
+			}
+		})
+	}
+}
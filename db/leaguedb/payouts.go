@@ -0,0 +1,93 @@
+package leaguedb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const OrderByWinnings = "winnings"
+
+// PayoutStructure is the percentage of the prize pool paid to each place, first
+// place first.
+type PayoutStructure []int
+
+var DefaultPayoutStructure = PayoutStructure{50, 30, 20}
+
+// Payout is what a place won. Player is empty when the game finished without
+// anyone recorded in that place.
+type Payout struct {
+	Place  int
+	Player string `json:",omitempty"`
+	Amount int
+}
+
+// ParsePayoutStructure reads comma separated percentages that add up to 100,
+// e.g. "65,35".
+func ParsePayoutStructure(input string) (PayoutStructure, error) {
+	var structure PayoutStructure
+	total := 0
+	for _, field := range strings.Split(input, ",") {
+		percent, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || percent <= 0 {
+			return nil, fmt.Errorf("bad percentage %q in payout structure %q", field, input)
+		}
+		structure = append(structure, percent)
+		total += percent
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("payout structure %q adds up to %d%%, want 100%%", input, total)
+	}
+	return structure, nil
+}
+
+// NewPayoutStructure parses a payout structure flag, which is the
+// DefaultPayoutStructure when it is empty.
+func NewPayoutStructure(input string) (PayoutStructure, error) {
+	if input == "" {
+		return DefaultPayoutStructure, nil
+	}
+	return ParsePayoutStructure(input)
+}
+
+// Pay splits the prize pool between the places, naming the players placed in
+// them. A game with fewer entrants than paid places only pays as many places as
+// it had entrants. What the unpaid places and rounding leave goes to first
+// place.
+func (s PayoutStructure) Pay(pool, entrants int, placings []string) []Payout {
+	if pool <= 0 || entrants <= 0 || len(s) == 0 {
+		return nil
+	}
+
+	payouts := make([]Payout, min(len(s), entrants))
+	paid := 0
+	for i := range payouts {
+		payouts[i] = Payout{Place: i + 1, Amount: pool * s[i] / 100}
+		if i < len(placings) {
+			payouts[i].Player = placings[i]
+		}
+		paid += payouts[i].Amount
+	}
+	payouts[0].Amount += pool - paid
+	return payouts
+}
+
+// AddWinnings adds each payout to what its player has won.
+func (l *League) AddWinnings(payouts []Payout) {
+	for _, payout := range payouts {
+		if payout.Player == "" {
+			continue
+		}
+		if l.Find(payout.Player) == nil {
+			*l = append(*l, Player{Name: payout.Player})
+		}
+		l.Find(payout.Player).Winnings += payout.Amount
+	}
+}
+
+func (l League) SortByWinnings() {
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].Winnings > l[j].Winnings
+	})
+}
@@ -0,0 +1,113 @@
+package leaguedb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPay(t *testing.T) {
+	cases := []struct {
+		name      string
+		structure PayoutStructure
+		pool      int
+		entrants  int
+		placings  []string
+		want      []Payout
+	}{
+		{
+			name:      "pays every place by percentage",
+			structure: PayoutStructure{50, 30, 20},
+			pool:      1000,
+			entrants:  10,
+			placings:  []string{"Ruth", "Chris", "Cleo"},
+			want:      []Payout{{1, "Ruth", 500}, {2, "Chris", 300}, {3, "Cleo", 200}},
+		},
+		{
+			name:      "rounding goes to the winner",
+			structure: PayoutStructure{50, 30, 20},
+			pool:      105,
+			entrants:  10,
+			placings:  []string{"Ruth", "Chris", "Cleo"},
+			want:      []Payout{{1, "Ruth", 53}, {2, "Chris", 31}, {3, "Cleo", 21}},
+		},
+		{
+			name:      "places nobody was recorded in are paid without a player",
+			structure: PayoutStructure{65, 35},
+			pool:      200,
+			entrants:  5,
+			placings:  []string{"Ruth"},
+			want:      []Payout{{1, "Ruth", 130}, {2, "", 70}},
+		},
+		{
+			name:      "fewer entrants than paid places",
+			structure: PayoutStructure{50, 30, 20},
+			pool:      100,
+			entrants:  2,
+			placings:  []string{"Ruth", "Chris"},
+			want:      []Payout{{1, "Ruth", 70}, {2, "Chris", 30}},
+		},
+		{
+			name:      "no prize pool",
+			structure: PayoutStructure{100},
+			pool:      0,
+			entrants:  4,
+			placings:  []string{"Ruth"},
+			want:      nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.structure.Pay(c.pool, c.entrants, c.placings)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParsePayoutStructure(t *testing.T) {
+	t.Run("comma separated percentages", func(t *testing.T) {
+		got, err := ParsePayoutStructure("65, 35")
+		assertNoError(t, err)
+		if !reflect.DeepEqual(got, PayoutStructure{65, 35}) {
+			t.Errorf("got %v, want %v", got, PayoutStructure{65, 35})
+		}
+	})
+
+	for _, input := range []string{"", "50,30", "50,,50", "half", "110,-10", "100,0"} {
+		t.Run("rejects "+input, func(t *testing.T) {
+			if _, err := ParsePayoutStructure(input); err == nil {
+				t.Errorf("expected an error for %q", input)
+			}
+		})
+	}
+
+	t.Run("an empty flag is the default structure", func(t *testing.T) {
+		got, err := NewPayoutStructure("")
+		assertNoError(t, err)
+		if !reflect.DeepEqual(got, DefaultPayoutStructure) {
+			t.Errorf("got %v, want %v", got, DefaultPayoutStructure)
+		}
+		if _, err := NewPayoutStructure("50,30"); err == nil {
+			t.Error("expected an error for a bad flag")
+		}
+	})
+}
+
+func TestWinnings(t *testing.T) {
+	doc := NewEmptyDocument()
+	doc.Players = League{{Name: "Chris", Wins: 2}}
+
+	doc.AddGame(GameRecord{Players: 3, Winner: "Ruth", Payouts: []Payout{{1, "Ruth", 200}, {2, "", 100}}})
+	doc.AddGame(GameRecord{Players: 3, Winner: "Chris", Payouts: []Payout{{1, "Chris", 150}, {2, "Ruth", 150}}})
+
+	if got := doc.Players.Find("Ruth").Winnings; got != 350 {
+		t.Errorf("got Ruth winning %d, want 350", got)
+	}
+	if got := doc.Players.Find("Chris").Winnings; got != 150 {
+		t.Errorf("got Chris winning %d, want 150", got)
+	}
+
+	assertNoError(t, doc.Players.OrderBy(OrderByWinnings))
+	assertOrder(t, doc.Players, "Ruth", "Chris")
+}
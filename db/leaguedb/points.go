@@ -0,0 +1,88 @@
+package leaguedb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PointsTable is the points given for each place, first place first. Places
+// past the end of the table score nothing.
+type PointsTable []int
+
+// DefaultPointsTable is the Formula 1 table.
+var DefaultPointsTable = PointsTable{25, 18, 15, 12, 10, 8, 6, 4, 2, 1}
+
+// Standing is a player's place in the points league.
+type Standing struct {
+	Name   string
+	Points int
+	Wins   int
+	Games  int
+}
+
+// ParsePointsTable reads comma separated points, e.g. "10,6,4,3,2,1".
+func ParsePointsTable(input string) (PointsTable, error) {
+	var table PointsTable
+	for _, field := range strings.Split(input, ",") {
+		points, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || points < 0 {
+			return nil, fmt.Errorf("bad points %q in points table %q", field, input)
+		}
+		table = append(table, points)
+	}
+	return table, nil
+}
+
+// NewPointsTable parses a points table flag, which is the DefaultPointsTable
+// when it is empty.
+func NewPointsTable(input string) (PointsTable, error) {
+	if input == "" {
+		return DefaultPointsTable, nil
+	}
+	return ParsePointsTable(input)
+}
+
+func (t PointsTable) For(place int) int {
+	if place < 1 || place > len(t) {
+		return 0
+	}
+	return t[place-1]
+}
+
+// Standings scores every game's finishing order with the table. Players are
+// ordered by points, then wins, then name.
+func Standings(games []GameRecord, table PointsTable) []Standing {
+	byName := map[string]*Standing{}
+	for _, game := range games {
+		for i, name := range game.Placings() {
+			standing, ok := byName[name]
+			if !ok {
+				standing = &Standing{Name: name}
+				byName[name] = standing
+			}
+			standing.Points += table.For(i + 1)
+			standing.Games++
+			if i == 0 {
+				standing.Wins++
+			}
+		}
+	}
+
+	standings := make([]Standing, 0, len(byName))
+	for _, standing := range byName {
+		standings = append(standings, *standing)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		return a.Name < b.Name
+	})
+	return standings
+}
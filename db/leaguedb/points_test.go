@@ -0,0 +1,67 @@
+package leaguedb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStandings(t *testing.T) {
+	games := []GameRecord{
+		{Players: 4, Winner: "Cleo", FinishingOrder: []string{"Cleo", "Chris", "Ruth"}},
+		{Players: 4, Winner: "Chris", FinishingOrder: []string{"Chris", "Ruth", "Cleo"}},
+		{Players: 3, Winner: "Ruth"},
+	}
+
+	t.Run("scores every place with the table", func(t *testing.T) {
+		got := Standings(games, PointsTable{10, 6, 4})
+		want := []Standing{
+			{Name: "Ruth", Points: 20, Wins: 1, Games: 3},
+			{Name: "Chris", Points: 16, Wins: 1, Games: 2},
+			{Name: "Cleo", Points: 14, Wins: 1, Games: 2},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("places past the table score nothing and ties are ordered by name", func(t *testing.T) {
+		got := Standings(games, PointsTable{1})
+		want := []Standing{
+			{Name: "Chris", Points: 1, Wins: 1, Games: 2},
+			{Name: "Cleo", Points: 1, Wins: 1, Games: 2},
+			{Name: "Ruth", Points: 1, Wins: 1, Games: 3},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestParsePointsTable(t *testing.T) {
+	t.Run("comma separated points", func(t *testing.T) {
+		got, err := ParsePointsTable("10, 6,4")
+		assertNoError(t, err)
+		if !reflect.DeepEqual(got, PointsTable{10, 6, 4}) {
+			t.Errorf("got %v, want %v", got, PointsTable{10, 6, 4})
+		}
+	})
+
+	for _, input := range []string{"", "10,,4", "ten", "5,-1"} {
+		t.Run("rejects "+input, func(t *testing.T) {
+			if _, err := ParsePointsTable(input); err == nil {
+				t.Errorf("expected an error for %q", input)
+			}
+		})
+	}
+
+	t.Run("an empty flag is the default table", func(t *testing.T) {
+		got, err := NewPointsTable("")
+		assertNoError(t, err)
+		if !reflect.DeepEqual(got, DefaultPointsTable) {
+			t.Errorf("got %v, want %v", got, DefaultPointsTable)
+		}
+		if _, err := NewPointsTable("ten"); err == nil {
+			t.Error("expected an error for a bad flag")
+		}
+	})
+}
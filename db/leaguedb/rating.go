@@ -0,0 +1,102 @@
+package leaguedb
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+const (
+	DefaultRating = 1500.0
+	RatingK       = 32.0
+
+	OrderByWins   = "wins"
+	OrderByRating = "rating"
+)
+
+// CurrentRating treats a player who never finished a rated game as having the
+// default rating.
+func (p Player) CurrentRating() float64 {
+	if p.Rating == 0 {
+		return DefaultRating
+	}
+	return p.Rating
+}
+
+// RateGame updates the Elo ratings of the players in a game. placings lists the
+// known finishers from first place down, and unplaced the other players known
+// by name, who tied below them. The rest of the field, up to numOfPlayers, are
+// anonymous opponents at the default rating who tied below everyone placed.
+// Each player is scored against every opponent, with K split across the field
+// so the size of a game doesn't inflate the change.
+func (l *League) RateGame(placings, unplaced []string, numOfPlayers int) {
+	rated := append(slices.Clone(placings), unplaced...)
+	numOfPlayers = max(numOfPlayers, len(rated))
+	if numOfPlayers < 2 || len(placings) == 0 {
+		return
+	}
+
+	before := make([]float64, len(rated))
+	places := make([]int, len(rated))
+	for i, name := range rated {
+		if l.Find(name) == nil {
+			*l = append(*l, Player{Name: name})
+		}
+		before[i] = l.Find(name).CurrentRating()
+		places[i] = min(i, len(placings))
+	}
+
+	k := RatingK / float64(numOfPlayers-1)
+	anonymous := numOfPlayers - len(rated)
+	for i, name := range rated {
+		var delta float64
+		for j := range rated {
+			if i == j {
+				continue
+			}
+			delta += score(places[i], places[j]) - expectedScore(before[i], before[j])
+		}
+		delta += float64(anonymous) * (score(places[i], len(placings)) - expectedScore(before[i], DefaultRating))
+
+		l.Find(name).Rating = math.Round((before[i]+k*delta)*10) / 10
+	}
+}
+
+func (l League) SortByRating() {
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].CurrentRating() > l[j].CurrentRating()
+	})
+}
+
+// OrderBy sorts the league by wins, rating or winnings. An empty order keeps the
+// league as it is.
+func (l League) OrderBy(order string) error {
+	switch order {
+	case "":
+	case OrderByWins:
+		l.SortByWins()
+	case OrderByRating:
+		l.SortByRating()
+	case OrderByWinnings:
+		l.SortByWinnings()
+	default:
+		return fmt.Errorf("can't order league by %q, use %q, %q or %q", order, OrderByWins, OrderByRating, OrderByWinnings)
+	}
+	return nil
+}
+
+func score(place, opponentPlace int) float64 {
+	switch {
+	case place < opponentPlace:
+		return 1
+	case place == opponentPlace:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+func expectedScore(rating, opponent float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponent-rating)/400))
+}
@@ -0,0 +1,115 @@
+package leaguedb
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRateGame(t *testing.T) {
+	cases := []struct {
+		name         string
+		league       League
+		placings     []string
+		unplaced     []string
+		numOfPlayers int
+		want         League
+	}{
+		{
+			name:         "heads up between equal players",
+			league:       League{{Name: "Cleo", Wins: 1}, {Name: "Chris"}},
+			placings:     []string{"Cleo", "Chris"},
+			numOfPlayers: 2,
+			want:         League{{Name: "Cleo", Wins: 1, Rating: 1516}, {Name: "Chris", Rating: 1484}},
+		},
+		{
+			name:         "winner against an anonymous field",
+			league:       League{{Name: "Cleo", Wins: 1}},
+			placings:     []string{"Cleo"},
+			numOfPlayers: 5,
+			want:         League{{Name: "Cleo", Wins: 1, Rating: 1516}},
+		},
+		{
+			name:         "upset win moves ratings further",
+			league:       League{{Name: "Cleo", Rating: 1400}, {Name: "Chris", Rating: 1600}},
+			placings:     []string{"Cleo", "Chris"},
+			numOfPlayers: 2,
+			want:         League{{Name: "Cleo", Rating: 1424.3}, {Name: "Chris", Rating: 1575.7}},
+		},
+		{
+			name:         "adds players missing from the league",
+			league:       League{},
+			placings:     []string{"Cleo"},
+			numOfPlayers: 2,
+			want:         League{{Name: "Cleo", Rating: 1516}},
+		},
+		{
+			name:         "registered players who weren't placed tie below the winner",
+			league:       League{{Name: "Ruth"}, {Name: "Chris"}, {Name: "Cleo"}},
+			placings:     []string{"Ruth"},
+			unplaced:     []string{"Chris", "Cleo"},
+			numOfPlayers: 3,
+			want:         League{{Name: "Ruth", Rating: 1516}, {Name: "Chris", Rating: 1492}, {Name: "Cleo", Rating: 1492}},
+		},
+		{
+			name:         "a single player game is not rated",
+			league:       League{{Name: "Cleo", Wins: 1}},
+			placings:     []string{"Cleo"},
+			numOfPlayers: 1,
+			want:         League{{Name: "Cleo", Wins: 1}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.league.RateGame(tt.placings, tt.unplaced, tt.numOfPlayers)
+			if !slices.Equal(tt.league, tt.want) {
+				t.Errorf("got %v, want %v", tt.league, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddGameRatesParticipants(t *testing.T) {
+	doc := NewEmptyDocument()
+	doc.AddGame(GameRecord{Players: 3, Winner: "Ruth", Participants: []string{"ruth", "Chris", "Cleo"}})
+
+	want := League{{Name: "Ruth", Wins: 1, Rating: 1516}, {Name: "Chris", Rating: 1492}, {Name: "Cleo", Rating: 1492}}
+	if !slices.Equal(doc.Players, want) {
+		t.Errorf("got %v, want %v", doc.Players, want)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	league := League{
+		{Name: "Cleo", Wins: 10, Rating: 1450},
+		{Name: "Chris", Wins: 3, Rating: 1620},
+		{Name: "Pepper", Wins: 5},
+	}
+
+	t.Run("by rating", func(t *testing.T) {
+		assertNoError(t, league.OrderBy(OrderByRating))
+		assertOrder(t, league, "Chris", "Pepper", "Cleo")
+	})
+
+	t.Run("by wins", func(t *testing.T) {
+		assertNoError(t, league.OrderBy(OrderByWins))
+		assertOrder(t, league, "Cleo", "Pepper", "Chris")
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		if err := league.OrderBy("luck"); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+
+func assertOrder(t testing.TB, league League, names ...string) {
+	t.Helper()
+	got := make([]string, len(league))
+	for i, p := range league {
+		got[i] = p.Name
+	}
+	if !slices.Equal(got, names) {
+		t.Errorf("got order %v, want %v", got, names)
+	}
+}
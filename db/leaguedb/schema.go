@@ -0,0 +1,107 @@
+package leaguedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// CurrentVersion is the schema version this binary writes. Bump it and add a
+// migration only when an older layout has to be transformed to be read.
+const CurrentVersion = 2
+
+var ErrNewerVersion = errors.New("league file was written by a newer version of the app")
+
+// Document is the versioned envelope stored in the league file.
+type Document struct {
+	Version  int
+	Players  League
+	Games    []GameRecord
+	Sessions []Session
+}
+
+type migration func(json.RawMessage) (json.RawMessage, error)
+
+// migrations[i] upgrades a document from version i+1 to version i+2.
+var migrations = []migration{
+	wrapBareLeague,
+}
+
+func NewDocument(r io.Reader) (Document, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return Document{}, fmt.Errorf("problem parsing league, %v", err)
+	}
+
+	version, err := documentVersion(raw)
+	if err != nil {
+		return Document{}, fmt.Errorf("problem parsing league, %v", err)
+	}
+	if version > CurrentVersion {
+		return Document{}, fmt.Errorf("league has version %d, this binary reads up to %d, %w", version, CurrentVersion, ErrNewerVersion)
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		raw, err = migrations[v-1](raw)
+		if err != nil {
+			return Document{}, fmt.Errorf("problem migrating league from version %d, %v", v, err)
+		}
+	}
+
+	var doc Document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return Document{}, fmt.Errorf("problem parsing league, %v", err)
+	}
+	if doc.Players == nil {
+		doc.Players = League{}
+	}
+	if doc.Games == nil {
+		doc.Games = []GameRecord{}
+	}
+	if doc.Sessions == nil {
+		doc.Sessions = []Session{}
+	}
+	return doc, nil
+}
+
+func NewEmptyDocument() Document {
+	return Document{Version: CurrentVersion, Players: League{}, Games: []GameRecord{}, Sessions: []Session{}}
+}
+
+func (d Document) Clone() Document {
+	d.Players = slices.Clone(d.Players)
+	d.Games = slices.Clone(d.Games)
+	d.Sessions = slices.Clone(d.Sessions)
+	return d
+}
+
+// documentVersion treats a bare JSON array as version 1, the layout used before
+// the envelope existed.
+func documentVersion(raw json.RawMessage) (int, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		return 1, nil
+	}
+
+	var header struct{ Version int }
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return 0, err
+	}
+	if header.Version < 1 {
+		return 0, fmt.Errorf("missing or invalid version %d", header.Version)
+	}
+	return header.Version, nil
+}
+
+func wrapBareLeague(raw json.RawMessage) (json.RawMessage, error) {
+	var players League
+	if err := json.Unmarshal(raw, &players); err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Version int
+		Players League
+	}{Version: 2, Players: players})
+}
@@ -0,0 +1,84 @@
+package leaguedb
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewDocument(t *testing.T) {
+	t.Run("migrates a bare league array", func(t *testing.T) {
+		doc, err := NewDocument(strings.NewReader(`[{"Name": "Cleo", "Wins": 10}]`))
+		assertNoError(t, err)
+
+		assertDocument(t, doc, Document{
+			Version: CurrentVersion,
+			Players: League{{Name: "Cleo", Wins: 10}},
+		})
+	})
+
+	t.Run("reads a document without games or sessions", func(t *testing.T) {
+		doc, err := NewDocument(strings.NewReader(`{"Version": 2, "Players": [{"Name": "Chris", "Wins": 33}]}`))
+		assertNoError(t, err)
+
+		assertDocument(t, doc, Document{
+			Version: CurrentVersion,
+			Players: League{{Name: "Chris", Wins: 33}},
+		})
+		if doc.Games == nil || len(doc.Games) != 0 {
+			t.Errorf("expected an empty games history, got %v", doc.Games)
+		}
+	})
+
+	t.Run("reads a current document", func(t *testing.T) {
+		current := fmt.Sprintf(`{"Version": %d, "Players": [], "Games": [{"Players": 4, "Winner": "Cleo"}]}`, CurrentVersion)
+		doc, err := NewDocument(strings.NewReader(current))
+		assertNoError(t, err)
+
+		if len(doc.Games) != 1 || doc.Games[0].Winner != "Cleo" {
+			t.Errorf("got games %v", doc.Games)
+		}
+	})
+
+	t.Run("refuses documents from a newer version", func(t *testing.T) {
+		_, err := NewDocument(strings.NewReader(`{"Version": 99, "Players": []}`))
+		if !errors.Is(err, ErrNewerVersion) {
+			t.Errorf("got %v, want %v", err, ErrNewerVersion)
+		}
+	})
+
+	t.Run("refuses documents without a version", func(t *testing.T) {
+		_, err := NewDocument(strings.NewReader(`{"Players": []}`))
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+
+	t.Run("NewLeague returns the players", func(t *testing.T) {
+		league, err := NewLeague(strings.NewReader(`[{"Name": "Cleo", "Wins": 10}]`))
+		assertNoError(t, err)
+
+		if !slices.Equal(league, League{{Name: "Cleo", Wins: 10}}) {
+			t.Errorf("got %v", league)
+		}
+	})
+}
+
+func assertDocument(t testing.TB, got, want Document) {
+	t.Helper()
+	if got.Version != want.Version {
+		t.Errorf("got version %d, want %d", got.Version, want.Version)
+	}
+	if !slices.Equal(got.Players, want.Players) {
+		t.Errorf("got players %v, want %v", got.Players, want.Players)
+	}
+}
+
+func assertNoError(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one, %v", err)
+	}
+}
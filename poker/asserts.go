@@ -9,6 +9,8 @@ import (
 
 func AssertGameNotStarted(t testing.TB, game *GameSpy) {
 	t.Helper()
+	game.mu.Lock()
+	defer game.mu.Unlock()
 	if game.StartCalled {
 		t.Errorf("game should not have started")
 	}
@@ -17,10 +19,14 @@ func AssertGameNotStarted(t testing.TB, game *GameSpy) {
 func AssertFinishCalledWith(t testing.TB, game *GameSpy, want string) {
 	t.Helper()
 	passed := retryUntil(500*time.Millisecond, func() bool {
+		game.mu.Lock()
+		defer game.mu.Unlock()
 		return game.FinishCalledWith == want
 	})
 
 	if !passed {
+		game.mu.Lock()
+		defer game.mu.Unlock()
 		t.Errorf("got %s but expected %s", game.FinishCalledWith, want)
 	}
 }
@@ -46,11 +52,22 @@ func AssertMessagesSentToUser(t testing.TB, stdout *bytes.Buffer, messages ...st
 
 func AssertGameStartedWith(t testing.TB, game *GameSpy, want int) {
 	t.Helper()
+	game.mu.Lock()
+	defer game.mu.Unlock()
 	if game.StartedCalledWith != want {
 		t.Errorf("wanted Start called with %d but got %d", want, game.StartedCalledWith)
 	}
 }
 
+func AssertGameStartedWithStructure(t testing.TB, game *GameSpy, want string) {
+	t.Helper()
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	if game.StartedWithStructure != want {
+		t.Errorf("got structure %q, want %q", game.StartedWithStructure, want)
+	}
+}
+
 func AssertScheduledAlert(t testing.TB, got, want ScheduledAlert) {
 	if got.Amount != want.Amount {
 		t.Errorf("got amount %d, want %d", got.Amount, want.Amount)
@@ -59,3 +76,19 @@ func AssertScheduledAlert(t testing.TB, got, want ScheduledAlert) {
 		t.Errorf("got scheduled time of %v, want %v", got.At, want.At)
 	}
 }
+
+func AssertAlertsStopped(t testing.TB, alerter *SpyBlindAlerter) {
+	t.Helper()
+	passed := retryUntil(500*time.Millisecond, func() bool {
+		for _, handle := range alerter.handles {
+			if !handle.Stopped {
+				return false
+			}
+		}
+		return len(alerter.handles) > 0
+	})
+
+	if !passed {
+		t.Errorf("expected all %d scheduled alerts to be stopped", len(alerter.handles))
+	}
+}
@@ -0,0 +1,616 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Actions a player takes on their turn. An all-in bets, raises or calls with
+// every chip the player has left.
+const (
+	CheckAction = "check"
+	BetAction   = "bet"
+	CallAction  = "call"
+	RaiseAction = "raise"
+	FoldAction  = "fold"
+	AllInAction = "allin"
+)
+
+// Actions the dealer takes during a hand.
+const (
+	AnteAction       = "ante"
+	SmallBlindAction = "small_blind"
+	BigBlindAction   = "big_blind"
+	DealAction       = "deal"
+	ShowAction       = "show"
+	ReturnAction     = "return"
+	WinAction        = "win"
+)
+
+var ErrBadAction = errors.New("bad action")
+
+type Street uint8
+
+const (
+	Preflop Street = iota
+	Flop
+	Turn
+	River
+	Showdown
+)
+
+var (
+	streetNames  = [...]string{"preflop", "flop", "turn", "river", "showdown"}
+	streetTitles = [...]string{"Preflop", "Flop", "Turn", "River", "Showdown"}
+)
+
+// streetCards is how many cards are dealt to the board for each street.
+var streetCards = [...]int{Flop: 3, Turn: 1, River: 1}
+
+func (s Street) String() string {
+	if int(s) >= len(streetNames) {
+		return "?"
+	}
+	return streetNames[s]
+}
+
+func (s Street) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Street) UnmarshalText(text []byte) error {
+	i := slices.Index(streetNames[:], string(text))
+	if i < 0 {
+		return fmt.Errorf("unknown street %q", text)
+	}
+	*s = Street(i)
+	return nil
+}
+
+// HandConfig seats the Players with their Stacks in order around the table.
+// Button is the index of the dealer. The Level's Blind is the big blind, the
+// small blind is half of it and everyone pays the Ante.
+type HandConfig struct {
+	Number  int
+	Players []string
+	Stacks  []int
+	Button  int
+	Level   BlindLevel
+}
+
+// HandAction is one thing that happened in a hand. Amount is the chips put in,
+// returned or won, except for bets and raises where it is what the player's
+// bet for the street was made up to. Cards are the ones dealt to the board or
+// shown, and Hand what the shown cards make. Pot is the side pot a win is
+// from, zero for the main pot.
+type HandAction struct {
+	Street Street
+	Player string `json:",omitempty"`
+	Kind   string
+	Amount int    `json:",omitempty"`
+	Pot    int    `json:",omitempty"`
+	AllIn  bool   `json:",omitempty"`
+	Cards  []Card `json:",omitempty"`
+	Hand   string `json:",omitempty"`
+}
+
+func (a HandAction) String() string {
+	var s string
+	switch a.Kind {
+	case AnteAction:
+		s = fmt.Sprintf("%s posts the ante %d", a.Player, a.Amount)
+	case SmallBlindAction:
+		s = fmt.Sprintf("%s posts the small blind %d", a.Player, a.Amount)
+	case BigBlindAction:
+		s = fmt.Sprintf("%s posts the big blind %d", a.Player, a.Amount)
+	case CheckAction:
+		s = a.Player + " checks"
+	case BetAction:
+		s = fmt.Sprintf("%s bets %d", a.Player, a.Amount)
+	case CallAction:
+		s = fmt.Sprintf("%s calls %d", a.Player, a.Amount)
+	case RaiseAction:
+		s = fmt.Sprintf("%s raises to %d", a.Player, a.Amount)
+	case FoldAction:
+		s = a.Player + " folds"
+	case DealAction:
+		return fmt.Sprintf("%s %s", streetTitles[a.Street], cardsString(a.Cards))
+	case ShowAction:
+		return fmt.Sprintf("%s shows %s, %s", a.Player, cardsString(a.Cards), a.Hand)
+	case ReturnAction:
+		return fmt.Sprintf("%d is returned to %s", a.Amount, a.Player)
+	case WinAction:
+		s = fmt.Sprintf("%s wins %d", a.Player, a.Amount)
+		if a.Pot > 0 {
+			s += fmt.Sprintf(" from side pot %d", a.Pot)
+		}
+		if a.Hand != "" {
+			s += " with " + a.Hand
+		}
+		return s
+	default:
+		return fmt.Sprintf("%s %s %d", a.Player, a.Kind, a.Amount)
+	}
+	if a.AllIn {
+		s += " and is all-in"
+	}
+	return s
+}
+
+func cardsString(cards []Card) string {
+	s := make([]string, len(cards))
+	for i, c := range cards {
+		s[i] = c.String()
+	}
+	return strings.Join(s, " ")
+}
+
+// HandState is what everyone at the table can see of a hand. Players' cards
+// are only in it once they are shown. ToCall and MinRaise are for the player
+// ToAct: what they need to call and the smallest bet or raise they can make
+// it.
+type HandState struct {
+	Number     int
+	Street     Street
+	Button     string
+	SmallBlind int
+	BigBlind   int
+	Ante       int    `json:",omitempty"`
+	Board      []Card `json:",omitempty"`
+	Pot        int
+	Seats      []SeatState
+	ToAct      string `json:",omitempty"`
+	ToCall     int    `json:",omitempty"`
+	MinRaise   int    `json:",omitempty"`
+	Over       bool   `json:",omitempty"`
+}
+
+// SeatState is a player's chips behind and in front of them.
+type SeatState struct {
+	Player string
+	Stack  int
+	Bet    int    `json:",omitempty"`
+	Folded bool   `json:",omitempty"`
+	AllIn  bool   `json:",omitempty"`
+	Cards  []Card `json:",omitempty"`
+}
+
+type seat struct {
+	player    string
+	stack     int
+	dealt     int
+	hole      []Card
+	bet       int
+	committed int
+	folded    bool
+	allIn     bool
+	acted     bool
+	shown     bool
+}
+
+// Hand is a hand of no-limit Texas Hold'em being played, from the blinds to
+// the showdown.
+type Hand struct {
+	number int
+	level  BlindLevel
+	button int
+	deck   *Deck
+	seats  []*seat
+	board  []Card
+	street Street
+
+	toAct      int
+	currentBet int
+	minRaise   int
+	actions    []HandAction
+	over       bool
+}
+
+// NewHand deals the players their cards from deck and posts the antes and
+// blinds. Heads up the button posts the small blind.
+func NewHand(config HandConfig, deck *Deck) (*Hand, error) {
+	if len(config.Players) < 2 || len(config.Stacks) != len(config.Players) {
+		return nil, fmt.Errorf("%w, want 2 or more players with a stack each, got %d players and %d stacks", ErrNotEnoughPlayers, len(config.Players), len(config.Stacks))
+	}
+	if config.Button < 0 || config.Button >= len(config.Players) {
+		return nil, fmt.Errorf("%w, no player at seat %d for the button", ErrBadPlayers, config.Button)
+	}
+	if config.Level.Break || config.Level.Blind <= 0 {
+		return nil, fmt.Errorf("%w, hands need a big blind, got %q", ErrBadAction, config.Level)
+	}
+
+	h := &Hand{
+		number: config.Number,
+		level:  config.Level,
+		button: config.Button,
+		deck:   deck,
+		toAct:  -1,
+	}
+	for i, player := range config.Players {
+		if config.Stacks[i] <= 0 {
+			return nil, fmt.Errorf("%w, %s has no chips", ErrBadPlayers, player)
+		}
+		if slices.Contains(config.Players[:i], player) {
+			return nil, fmt.Errorf("%w, %s is seated twice", ErrBadPlayers, player)
+		}
+		h.seats = append(h.seats, &seat{player: player, stack: config.Stacks[i], dealt: config.Stacks[i]})
+	}
+
+	holes, err := deck.DealHoleCards(len(h.seats))
+	if err != nil {
+		return nil, err
+	}
+	for i, hole := range holes {
+		h.seats[h.after(h.button, i+1)].hole = hole
+	}
+
+	if ante := config.Level.Ante; ante > 0 {
+		for i := range h.seats {
+			s := h.seats[h.after(h.button, i+1)]
+			h.post(AnteAction, s, ante)
+			s.bet = 0
+		}
+	}
+	small, big := h.after(h.button, 1), h.after(h.button, 2)
+	if len(h.seats) == 2 {
+		small, big = h.button, h.after(h.button, 1)
+	}
+	h.post(SmallBlindAction, h.seats[small], config.Level.Blind/2)
+	h.post(BigBlindAction, h.seats[big], config.Level.Blind)
+
+	h.currentBet = config.Level.Blind
+	h.minRaise = config.Level.Blind
+	h.toAct = big
+	h.next()
+	return h, nil
+}
+
+// after returns the seat n places to the left of seat i.
+func (h *Hand) after(i, n int) int {
+	return (i + n) % len(h.seats)
+}
+
+// post puts in a forced bet, or what the seat has left if that is less.
+func (h *Hand) post(kind string, s *seat, chips int) {
+	if s.stack > 0 {
+		h.log(kind, s, h.put(s, min(chips, s.stack)))
+	}
+}
+
+// put moves chips from the seat's stack into the pot and returns how many.
+func (h *Hand) put(s *seat, chips int) int {
+	s.stack -= chips
+	s.bet += chips
+	s.committed += chips
+	s.allIn = s.stack == 0
+	return chips
+}
+
+func (h *Hand) log(kind string, s *seat, amount int) {
+	h.actions = append(h.actions, HandAction{Street: h.street, Player: s.player, Kind: kind, Amount: amount, AllIn: s.allIn})
+}
+
+// Act takes the action of the player whose turn it is and plays the hand on
+// until someone else has to act or it is over. Amount is what a bet or raise
+// makes the player's bet for the street. It returns what happened.
+func (h *Hand) Act(player, kind string, amount int) ([]HandAction, error) {
+	if h.over {
+		return nil, fmt.Errorf("%w, hand %d is over", ErrBadAction, h.number)
+	}
+	s := h.seats[h.toAct]
+	if !strings.EqualFold(s.player, strings.TrimSpace(player)) {
+		return nil, fmt.Errorf("%w, it is %s's turn", ErrBadAction, s.player)
+	}
+
+	if kind == AllInAction {
+		amount = s.bet + s.stack
+		switch {
+		case amount <= h.currentBet:
+			kind = CallAction
+		case h.currentBet == 0:
+			kind = BetAction
+		default:
+			kind = RaiseAction
+		}
+	}
+
+	done := len(h.actions)
+	switch kind {
+	case FoldAction:
+		s.folded = true
+		h.log(kind, s, 0)
+	case CheckAction:
+		if s.bet < h.currentBet {
+			return nil, fmt.Errorf("%w, %s can't check with %d to call", ErrBadAction, s.player, h.currentBet-s.bet)
+		}
+		h.log(kind, s, 0)
+	case CallAction:
+		if s.bet >= h.currentBet {
+			return nil, fmt.Errorf("%w, there is nothing to call, check instead", ErrBadAction)
+		}
+		h.log(kind, s, h.put(s, min(h.currentBet-s.bet, s.stack)))
+	case BetAction:
+		if h.currentBet > 0 {
+			return nil, fmt.Errorf("%w, there is already a bet of %d, raise instead", ErrBadAction, h.currentBet)
+		}
+		if err := h.raiseTo(s, amount); err != nil {
+			return nil, err
+		}
+		h.log(kind, s, amount)
+	case RaiseAction:
+		if h.currentBet == 0 {
+			return nil, fmt.Errorf("%w, there is nothing to raise, bet instead", ErrBadAction)
+		}
+		if s.acted {
+			return nil, fmt.Errorf("%w, the betting wasn't reopened, %s can only call or fold", ErrBadAction, s.player)
+		}
+		if err := h.raiseTo(s, amount); err != nil {
+			return nil, err
+		}
+		h.log(kind, s, amount)
+	default:
+		return nil, fmt.Errorf("%w, unknown action %q", ErrBadAction, kind)
+	}
+	s.acted = true
+
+	h.next()
+	return slices.Clone(h.actions[done:]), nil
+}
+
+// raiseTo makes the seat's bet up to to. Anything short of a full raise has to
+// be all-in, and only a full raise reopens the betting to players who have
+// already acted.
+func (h *Hand) raiseTo(s *seat, to int) error {
+	if to > s.bet+s.stack {
+		return fmt.Errorf("%w, %s only has %d", ErrBadAction, s.player, s.bet+s.stack)
+	}
+	if to <= h.currentBet {
+		return fmt.Errorf("%w, the bet is already %d", ErrBadAction, h.currentBet)
+	}
+	raise := to - h.currentBet
+	if raise < h.minRaise && to < s.bet+s.stack {
+		return fmt.Errorf("%w, the smallest you can make it is %d", ErrBadAction, h.currentBet+h.minRaise)
+	}
+
+	if raise >= h.minRaise {
+		h.minRaise = raise
+		for _, other := range h.seats {
+			other.acted = false
+		}
+	}
+	h.currentBet = to
+	h.put(s, to-s.bet)
+	return nil
+}
+
+// next moves the turn to the next player who has to act, ending the street or
+// the hand when nobody does.
+func (h *Hand) next() {
+	if len(h.live()) == 1 {
+		h.returnUncalled()
+		h.win()
+		return
+	}
+	for n := 1; n <= len(h.seats); n++ {
+		if i := h.after(h.toAct, n); h.mustAct(h.seats[i]) {
+			h.toAct = i
+			return
+		}
+	}
+	h.endStreet()
+}
+
+func (h *Hand) mustAct(s *seat) bool {
+	if s.folded || s.allIn {
+		return false
+	}
+	if s.bet < h.currentBet {
+		return true
+	}
+	return !s.acted && h.canAct() > 1
+}
+
+// canAct counts the players who still have chips to bet with.
+func (h *Hand) canAct() int {
+	n := 0
+	for _, s := range h.seats {
+		if !s.folded && !s.allIn {
+			n++
+		}
+	}
+	return n
+}
+
+func (h *Hand) live() []*seat {
+	var live []*seat
+	for _, s := range h.seats {
+		if !s.folded {
+			live = append(live, s)
+		}
+	}
+	return live
+}
+
+// endStreet deals the next street, or runs the board out when there is no one
+// left to bet, and goes to the showdown after the river.
+func (h *Hand) endStreet() {
+	h.returnUncalled()
+	for _, s := range h.seats {
+		s.bet = 0
+		s.acted = false
+	}
+	h.currentBet = 0
+	h.minRaise = h.level.Blind
+
+	if h.street == River {
+		h.showdown()
+		return
+	}
+	h.street++
+	cards, _ := h.deck.DealCommunity(streetCards[h.street])
+	h.board = append(h.board, cards...)
+	h.actions = append(h.actions, HandAction{Street: h.street, Kind: DealAction, Cards: cards})
+
+	h.toAct = h.button
+	h.next()
+}
+
+// returnUncalled gives back the part of the biggest bet nobody could call.
+func (h *Hand) returnUncalled() {
+	var top *seat
+	called := 0
+	for _, s := range h.seats {
+		switch {
+		case top == nil || s.bet > top.bet:
+			if top != nil {
+				called = max(called, top.bet)
+			}
+			top = s
+		default:
+			called = max(called, s.bet)
+		}
+	}
+	if uncalled := top.bet - called; uncalled > 0 {
+		top.bet -= uncalled
+		top.committed -= uncalled
+		top.stack += uncalled
+		top.allIn = false
+		h.actions = append(h.actions, HandAction{Street: h.street, Player: top.player, Kind: ReturnAction, Amount: uncalled})
+	}
+}
+
+func (h *Hand) pot() int {
+	pot := 0
+	for _, s := range h.seats {
+		pot += s.committed
+	}
+	return pot
+}
+
+// win gives the pot to the last player in when everyone else folded.
+func (h *Hand) win() {
+	winner := h.live()[0]
+	pot := h.pot()
+	winner.stack += pot
+	h.actions = append(h.actions, HandAction{Street: h.street, Player: winner.player, Kind: WinAction, Amount: pot})
+	h.end()
+}
+
+// showdown shows the cards of everyone still in, starting left of the button,
+// and gives the main pot and each side pot to the best hand that can win it.
+func (h *Hand) showdown() {
+	h.street = Showdown
+
+	var shown []*seat
+	var holes [][]Card
+	for _, s := range h.fromButton() {
+		if !s.folded {
+			shown = append(shown, s)
+			holes = append(holes, s.hole)
+		}
+	}
+	ranks, _ := RankHands(h.board, holes)
+	byPlayer := map[string]HandRank{}
+	seats := map[string]*seat{}
+	for i, s := range shown {
+		s.shown = true
+		byPlayer[s.player] = ranks[i]
+		seats[s.player] = s
+		h.actions = append(h.actions, HandAction{Street: h.street, Player: s.player, Kind: ShowAction, Cards: s.hole, Hand: ranks[i].String()})
+	}
+
+	shares, _ := SplitPots(h.pots(), byPlayer)
+	for _, share := range shares {
+		seats[share.Player].stack += share.Amount
+		h.actions = append(h.actions, HandAction{Street: h.street, Player: share.Player, Kind: WinAction, Amount: share.Amount, Pot: share.Pot, Hand: byPlayer[share.Player].String()})
+	}
+	h.end()
+}
+
+// fromButton returns the seats in order from the left of the button.
+func (h *Hand) fromButton() []*seat {
+	seats := make([]*seat, len(h.seats))
+	for n := range h.seats {
+		seats[n] = h.seats[h.after(h.button, n+1)]
+	}
+	return seats
+}
+
+func (h *Hand) pots() []Pot {
+	var contributions []Contribution
+	for _, s := range h.fromButton() {
+		contributions = append(contributions, Contribution{Player: s.player, Amount: s.committed, Folded: s.folded})
+	}
+	return Pots(contributions)
+}
+
+func (h *Hand) end() {
+	for _, s := range h.seats {
+		s.bet = 0
+		s.committed = 0
+	}
+	h.toAct = -1
+	h.over = true
+}
+
+func (h *Hand) Number() int {
+	return h.number
+}
+
+func (h *Hand) Over() bool {
+	return h.over
+}
+
+// Actions returns everything that has happened in the hand so far.
+func (h *Hand) Actions() []HandAction {
+	return slices.Clone(h.actions)
+}
+
+// Stacks returns what each player has behind, in seat order.
+func (h *Hand) Stacks() []int {
+	stacks := make([]int, len(h.seats))
+	for i, s := range h.seats {
+		stacks[i] = s.stack
+	}
+	return stacks
+}
+
+// HoleCards returns the cards the player was dealt.
+func (h *Hand) HoleCards(player string) ([]Card, error) {
+	for _, s := range h.seats {
+		if strings.EqualFold(s.player, strings.TrimSpace(player)) {
+			return slices.Clone(s.hole), nil
+		}
+	}
+	return nil, fmt.Errorf("%w, %s isn't in hand %d", ErrBadAction, player, h.number)
+}
+
+func (h *Hand) State() HandState {
+	state := HandState{
+		Number:     h.number,
+		Street:     h.street,
+		Button:     h.seats[h.button].player,
+		SmallBlind: h.level.Blind / 2,
+		BigBlind:   h.level.Blind,
+		Ante:       h.level.Ante,
+		Board:      slices.Clone(h.board),
+		Pot:        h.pot(),
+		Over:       h.over,
+	}
+	for _, s := range h.seats {
+		seat := SeatState{Player: s.player, Stack: s.stack, Bet: s.bet, Folded: s.folded, AllIn: s.allIn}
+		if s.shown {
+			seat.Cards = slices.Clone(s.hole)
+		}
+		state.Seats = append(state.Seats, seat)
+	}
+	if !h.over {
+		s := h.seats[h.toAct]
+		state.ToAct = s.player
+		state.ToCall = min(h.currentBet-s.bet, s.stack)
+		state.MinRaise = min(h.currentBet+h.minRaise, s.bet+s.stack)
+	}
+	return state
+}
@@ -0,0 +1,263 @@
+package poker
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// stackedDeck returns a deck with cards on top, in order, and the rest of the
+// cards under them.
+func stackedDeck(t testing.TB, cards string) *Deck {
+	t.Helper()
+	top, err := ParseCards(cards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deck := &Deck{cards: top}
+	for _, c := range NewDeck().cards {
+		if !slices.Contains(top, c) {
+			deck.cards = append(deck.cards, c)
+		}
+	}
+	return deck
+}
+
+func newTestHand(t testing.TB, players string, stacks []int, level BlindLevel, deck *Deck) *Hand {
+	t.Helper()
+	if deck == nil {
+		deck = NewDeck()
+	}
+	hand, err := NewHand(HandConfig{Number: 1, Players: strings.Fields(players), Stacks: stacks, Level: level}, deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hand
+}
+
+// act takes an action written as "{Player} {action} {amount}".
+func act(hand *Hand, action string) ([]HandAction, error) {
+	fields := strings.Fields(action)
+	amount := 0
+	if len(fields) > 2 {
+		amount, _ = strconv.Atoi(fields[2])
+	}
+	return hand.Act(fields[0], fields[1], amount)
+}
+
+// play acts out the actions in turn, failing on the first one that is refused.
+func play(t testing.TB, hand *Hand, actions ...string) {
+	t.Helper()
+	for _, action := range actions {
+		if _, err := act(hand, action); err != nil {
+			t.Fatalf("could not play %q, %v", action, err)
+		}
+	}
+}
+
+func assertStacks(t testing.TB, hand *Hand, want ...int) {
+	t.Helper()
+	if got := hand.Stacks(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got stacks %v, want %v", got, want)
+	}
+}
+
+func assertToAct(t testing.TB, hand *Hand, player string, street Street) {
+	t.Helper()
+	state := hand.State()
+	if state.ToAct != player || state.Street != street {
+		t.Errorf("got %s to act on the %s, want %s on the %s", state.ToAct, state.Street, player, street)
+	}
+}
+
+func TestHandBetting(t *testing.T) {
+	level := BlindLevel{Blind: 100}
+	stacks := []int{1000, 1000, 1000}
+
+	t.Run("the blinds are posted left of the button and the next player starts", func(t *testing.T) {
+		hand := newTestHand(t, "Ann Bob Cat", stacks, level, nil)
+
+		state := hand.State()
+		if state.ToAct != "Ann" || state.ToCall != 100 || state.MinRaise != 200 || state.Pot != 150 {
+			t.Errorf("got %+v, want Ann to call 100 or raise to 200 into 150", state)
+		}
+		assertStacks(t, hand, 1000, 950, 900)
+	})
+
+	t.Run("heads up the button posts the small blind and acts first only preflop", func(t *testing.T) {
+		hand := newTestHand(t, "Ann Bob", []int{1000, 1000}, level, nil)
+
+		assertToAct(t, hand, "Ann", Preflop)
+		assertStacks(t, hand, 950, 900)
+
+		play(t, hand, "Ann call", "Bob check")
+		assertToAct(t, hand, "Bob", Flop)
+	})
+
+	t.Run("the big blind gets an option", func(t *testing.T) {
+		hand := newTestHand(t, "Ann Bob Cat", stacks, level, nil)
+
+		play(t, hand, "Ann call", "Bob call")
+		assertToAct(t, hand, "Cat", Preflop)
+
+		play(t, hand, "Cat raise 300", "Ann call", "Bob fold")
+		assertToAct(t, hand, "Cat", Flop)
+		if got := hand.State(); got.Pot != 700 || len(got.Board) != 3 {
+			t.Errorf("got pot %d and board %v, want 700 and a flop", got.Pot, got.Board)
+		}
+	})
+
+	t.Run("everyone folding gives the pot to the last player", func(t *testing.T) {
+		hand := newTestHand(t, "Ann Bob Cat", stacks, level, nil)
+
+		play(t, hand, "Ann fold", "Bob fold")
+
+		if !hand.Over() {
+			t.Fatal("expected the hand to be over")
+		}
+		assertStacks(t, hand, 1000, 950, 1050)
+	})
+
+	t.Run("raises have to be at least the last raise", func(t *testing.T) {
+		hand := newTestHand(t, "Ann Bob Cat", stacks, level, nil)
+		play(t, hand, "Ann raise 350")
+
+		if _, err := hand.Act("Bob", RaiseAction, 500); !errors.Is(err, ErrBadAction) {
+			t.Errorf("got %v raising by less than 250, want ErrBadAction", err)
+		}
+		play(t, hand, "Bob raise 600")
+		if got := hand.State().MinRaise; got != 850 {
+			t.Errorf("got min raise to %d, want 850", got)
+		}
+	})
+
+	t.Run("a short all-in doesn't reopen the betting", func(t *testing.T) {
+		hand := newTestHand(t, "Ann Bob Cat", []int{1000, 1000, 450}, level, nil)
+
+		play(t, hand, "Ann raise 300", "Bob call", "Cat allin")
+
+		if _, err := hand.Act("Ann", RaiseAction, 1000); !errors.Is(err, ErrBadAction) {
+			t.Errorf("got %v re-raising a short all-in, want ErrBadAction", err)
+		}
+		play(t, hand, "Ann call", "Bob call")
+		assertToAct(t, hand, "Bob", Flop)
+		assertStacks(t, hand, 550, 550, 0)
+	})
+
+	cases := []struct {
+		name   string
+		played []string
+		action string
+	}{
+		{"out of turn", nil, "Bob call"},
+		{"checking a bet", nil, "Ann check"},
+		{"calling nothing", []string{"Ann call", "Bob call"}, "Cat call"},
+		{"betting into a bet", nil, "Ann bet 300"},
+		{"raising with no bet", []string{"Ann call", "Bob call", "Cat check"}, "Bob raise 200"},
+		{"betting less than the big blind", []string{"Ann call", "Bob call", "Cat check"}, "Bob bet 50"},
+		{"betting more than the stack", nil, "Ann raise 1200"},
+		{"an unknown action", nil, "Ann shove"},
+	}
+	for _, c := range cases {
+		t.Run("refuses "+c.name, func(t *testing.T) {
+			hand := newTestHand(t, "Ann Bob Cat", stacks, level, nil)
+			play(t, hand, c.played...)
+
+			if _, err := act(hand, c.action); !errors.Is(err, ErrBadAction) {
+				t.Errorf("got %v, want ErrBadAction", err)
+			}
+		})
+	}
+}
+
+func TestHandShowdown(t *testing.T) {
+	level := BlindLevel{Blind: 100}
+
+	t.Run("the best hand wins at the showdown", func(t *testing.T) {
+		// Bob and Cat are dealt first, left of the button, then the board
+		// comes with a burn card before each street.
+		deck := stackedDeck(t, "Ah Kd 2c As Kc 3d 7h 2s 2h 9c 4s Js 5h 8d")
+		hand := newTestHand(t, "Ann Bob Cat", []int{1000, 1000, 1000}, level, deck)
+
+		play(t, hand,
+			"Ann call", "Bob call", "Cat check",
+			"Bob check", "Cat bet 200", "Ann fold", "Bob call",
+			"Bob check", "Cat check",
+			"Bob check", "Cat check",
+		)
+
+		if !hand.Over() {
+			t.Fatal("expected the hand to be over")
+		}
+		assertStacks(t, hand, 900, 1400, 700)
+
+		actions := hand.Actions()
+		if got := actions[len(actions)-1].String(); got != "Bob wins 700 with two pair, Aces and Deuces" {
+			t.Errorf("got %q, want Bob to win with two pair", got)
+		}
+		for _, seat := range hand.State().Seats {
+			if shown := len(seat.Cards) > 0; shown == seat.Folded {
+				t.Errorf("got %s showing %v, want only the players at the showdown", seat.Player, seat.Cards)
+			}
+		}
+	})
+
+	t.Run("equal hands split the pot with the odd chip left of the button", func(t *testing.T) {
+		deck := stackedDeck(t, "2c 2d 4c 3c 3d 4d 5s Ah Kh Qh 6s Jh 7s Th")
+		hand := newTestHand(t, "Ann Bob Cat", []int{1000, 1000, 1000}, BlindLevel{Blind: 100, Ante: 1}, deck)
+
+		play(t, hand,
+			"Ann fold", "Bob call", "Cat check",
+			"Bob check", "Cat check",
+			"Bob check", "Cat check",
+			"Bob check", "Cat check",
+		)
+
+		assertStacks(t, hand, 999, 1001, 1000)
+	})
+
+	t.Run("all-ins for different amounts win their own pots", func(t *testing.T) {
+		deck := stackedDeck(t, "Kh 2c Ah Kd 7d As 3s 4d 8c 9h 5s Jc 6s Qs")
+		hand := newTestHand(t, "Ann Bob Cat", []int{300, 600, 1000}, level, deck)
+
+		play(t, hand, "Ann allin", "Bob allin", "Cat call")
+
+		assertStacks(t, hand, 900, 600, 400)
+		var wins []string
+		for _, a := range hand.Actions() {
+			if a.Kind == WinAction {
+				wins = append(wins, a.String())
+			}
+		}
+		want := []string{"Ann wins 900 with a pair of Aces", "Bob wins 600 from side pot 1 with a pair of Kings"}
+		if !reflect.DeepEqual(wins, want) {
+			t.Errorf("got %q, want %q", wins, want)
+		}
+	})
+
+	t.Run("a bet nobody can call is returned", func(t *testing.T) {
+		deck := stackedDeck(t, "As Kd Ad Kc")
+		hand := newTestHand(t, "Ann Bob", []int{1000, 400}, level, deck)
+
+		play(t, hand, "Ann allin", "Bob allin")
+
+		var returned []HandAction
+		for _, a := range hand.Actions() {
+			if a.Kind == ReturnAction {
+				returned = append(returned, a)
+			}
+		}
+		if len(returned) != 1 || returned[0].Player != "Ann" || returned[0].Amount != 600 {
+			t.Errorf("got %v returned, want Ann to get 600 back", returned)
+		}
+		if total := hand.Stacks()[0] + hand.Stacks()[1]; total != 1400 {
+			t.Errorf("got %d chips at the table, want 1400", total)
+		}
+		if got := hand.State(); !got.Over || len(got.Board) != 5 {
+			t.Errorf("got board %v, want it run out to the showdown", got.Board)
+		}
+	})
+}
@@ -4,20 +4,40 @@ import (
 	"fmt"
 	"io"
 	"time"
+
+	"github.com/shortykevich/go-with-tests-app/clock"
 )
 
 type BlindAlerter interface {
-	ScheduleAlertAt(time.Duration, int, io.Writer)
+	ScheduleAlertAt(time.Duration, BlindLevel, io.Writer) clock.Timer
 }
 
-type BlindAlerterFunc func(time.Duration, int, io.Writer)
+type BlindAlerterFunc func(time.Duration, BlindLevel, io.Writer) clock.Timer
+
+func (b BlindAlerterFunc) ScheduleAlertAt(duration time.Duration, level BlindLevel, to io.Writer) clock.Timer {
+	return b(duration, level, to)
+}
+
+// LevelWriter is implemented by writers that want the blind level itself rather
+// than its text, like the websocket protocol.
+type LevelWriter interface {
+	WriteLevel(BlindLevel) error
+}
 
-func (b BlindAlerterFunc) ScheduleAlertAt(duration time.Duration, amount int, to io.Writer) {
-	b(duration, amount, to)
+// NewAlerter returns a BlindAlerter that writes each level to its writer when
+// the clock reaches it.
+func NewAlerter(c clock.Clock) BlindAlerterFunc {
+	return func(duration time.Duration, level BlindLevel, to io.Writer) clock.Timer {
+		return c.AfterFunc(duration, func() {
+			if lw, ok := to.(LevelWriter); ok {
+				lw.WriteLevel(level)
+				return
+			}
+			fmt.Fprintf(to, "%s\n", level)
+		})
+	}
 }
 
-func Alerter(duration time.Duration, amount int, to io.Writer) {
-	time.AfterFunc(duration, func() {
-		fmt.Fprintf(to, "Blind is now %d\n", amount)
-	})
+func Alerter(duration time.Duration, level BlindLevel, to io.Writer) clock.Timer {
+	return NewAlerter(clock.Real{})(duration, level, to)
 }
@@ -0,0 +1,161 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+const DefaultBlindStructure = "standard"
+
+var ErrUnknownBlindStructure = errors.New("unknown blind structure")
+
+// BlindLevel is one step of a blind structure. A level without Minutes lasts
+// BaseTime plus one minute per player, like the original hard-coded schedule.
+type BlindLevel struct {
+	Blind   int  `json:",omitempty"`
+	Ante    int  `json:",omitempty"`
+	Minutes int  `json:",omitempty"`
+	Break   bool `json:",omitempty"`
+}
+
+type BlindStructure struct {
+	Name   string
+	Levels []BlindLevel
+}
+
+func (l BlindLevel) String() string {
+	switch {
+	case l.Break && l.Minutes > 0:
+		return fmt.Sprintf("Break for %d minutes", l.Minutes)
+	case l.Break:
+		return "Break"
+	case l.Ante > 0:
+		return fmt.Sprintf("Blind is now %d, ante %d", l.Blind, l.Ante)
+	default:
+		return fmt.Sprintf("Blind is now %d", l.Blind)
+	}
+}
+
+func (l BlindLevel) Duration(numOfPlayers int) time.Duration {
+	if l.Minutes > 0 {
+		return time.Duration(l.Minutes) * time.Minute
+	}
+	return time.Duration(BaseTime+numOfPlayers) * time.Minute
+}
+
+func PresetBlindStructures() []BlindStructure {
+	return []BlindStructure{
+		{
+			Name:   DefaultBlindStructure,
+			Levels: levels(0, 100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000),
+		},
+		{
+			Name:   "turbo",
+			Levels: levels(5, 100, 200, 400, 600, 1000, 2000, 4000, 8000),
+		},
+		{
+			Name: "deepstack",
+			Levels: []BlindLevel{
+				{Blind: 50, Minutes: 20},
+				{Blind: 100, Minutes: 20},
+				{Blind: 150, Minutes: 20},
+				{Blind: 200, Ante: 25, Minutes: 20},
+				{Break: true, Minutes: 10},
+				{Blind: 300, Ante: 25, Minutes: 20},
+				{Blind: 400, Ante: 50, Minutes: 20},
+				{Blind: 600, Ante: 75, Minutes: 20},
+				{Blind: 800, Ante: 100, Minutes: 20},
+				{Break: true, Minutes: 10},
+				{Blind: 1000, Ante: 100, Minutes: 20},
+				{Blind: 1500, Ante: 200, Minutes: 20},
+				{Blind: 2000, Ante: 300, Minutes: 20},
+				{Blind: 4000, Ante: 500, Minutes: 20},
+			},
+		},
+	}
+}
+
+// LoadBlindStructures reads a JSON array of blind structures.
+func LoadBlindStructures(r io.Reader) ([]BlindStructure, error) {
+	var structures []BlindStructure
+	if err := json.NewDecoder(r).Decode(&structures); err != nil {
+		return nil, fmt.Errorf("problem parsing blind structures, %v", err)
+	}
+	for _, s := range structures {
+		if err := s.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return structures, nil
+}
+
+func BlindStructuresFromFile(path string) ([]BlindStructure, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+	defer file.Close()
+
+	return LoadBlindStructures(file)
+}
+
+func (s BlindStructure) validate() error {
+	if s.Name == "" {
+		return errors.New("blind structure without a name")
+	}
+	if len(s.Levels) == 0 {
+		return fmt.Errorf("blind structure %q has no levels", s.Name)
+	}
+	for i, l := range s.Levels {
+		if !l.Break && l.Blind <= 0 {
+			return fmt.Errorf("level %d of blind structure %q needs a blind", i+1, s.Name)
+		}
+		if l.Ante < 0 || l.Minutes < 0 {
+			return fmt.Errorf("level %d of blind structure %q has a negative value", i+1, s.Name)
+		}
+	}
+	return nil
+}
+
+type blindStructures map[string]BlindStructure
+
+func newBlindStructures(structures []BlindStructure) blindStructures {
+	byName := blindStructures{}
+	for _, s := range structures {
+		byName[s.Name] = s
+	}
+	return byName
+}
+
+func (b blindStructures) get(name string) (BlindStructure, error) {
+	if name == "" {
+		name = DefaultBlindStructure
+	}
+	s, ok := b[name]
+	if !ok {
+		return BlindStructure{}, fmt.Errorf("%w %q", ErrUnknownBlindStructure, name)
+	}
+	return s, nil
+}
+
+func (b blindStructures) names() []string {
+	names := make([]string, 0, len(b))
+	for name := range b {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func levels(minutes int, blinds ...int) []BlindLevel {
+	levels := make([]BlindLevel, len(blinds))
+	for i, blind := range blinds {
+		levels[i] = BlindLevel{Blind: blind, Minutes: minutes}
+	}
+	return levels
+}
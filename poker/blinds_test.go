@@ -0,0 +1,123 @@
+package poker
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
+)
+
+func TestBlindStructures(t *testing.T) {
+	t.Run("turbo levels last five minutes whatever the table size", func(t *testing.T) {
+		blindAlerter := &SpyBlindAlerter{}
+		game := NewTexasHoldem(blindAlerter, tutils.NewStubStorage())
+
+		_, err := game.Start(GameConfig{NumOfPlayers: 9, Structure: "turbo"}, io.Discard)
+		tutils.AssertNoError(t, err)
+
+		cases := []ScheduledAlert{
+			{0 * time.Second, 100},
+			{5 * time.Minute, 200},
+			{10 * time.Minute, 400},
+			{15 * time.Minute, 600},
+		}
+		checkSchedulingCases(t, cases, blindAlerter)
+	})
+
+	t.Run("structures loaded from a file can be picked by name", func(t *testing.T) {
+		structures, err := LoadBlindStructures(strings.NewReader(`[
+			{"Name": "quick", "Levels": [
+				{"Blind": 50, "Minutes": 3},
+				{"Break": true, "Minutes": 2},
+				{"Blind": 100, "Ante": 10, "Minutes": 3}
+			]}
+		]`))
+		tutils.AssertNoError(t, err)
+
+		blindAlerter := &SpyBlindAlerter{}
+		game := NewTexasHoldem(blindAlerter, tutils.NewStubStorage())
+		game.AddBlindStructures(structures...)
+
+		_, err = game.Start(GameConfig{NumOfPlayers: 4, Structure: "quick"}, io.Discard)
+		tutils.AssertNoError(t, err)
+
+		cases := []ScheduledAlert{
+			{0 * time.Second, 50},
+			{3 * time.Minute, 0},
+			{5 * time.Minute, 100},
+		}
+		checkSchedulingCases(t, cases, blindAlerter)
+	})
+
+	t.Run("unknown structures are refused", func(t *testing.T) {
+		game := NewTexasHoldem(&SpyBlindAlerter{}, tutils.NewStubStorage())
+
+		_, err := game.Start(GameConfig{NumOfPlayers: 4, Structure: "glacial"}, io.Discard)
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+
+	t.Run("invalid structures are not loaded", func(t *testing.T) {
+		invalid := []string{
+			`[{"Levels": [{"Blind": 50}]}]`,
+			`[{"Name": "empty", "Levels": []}]`,
+			`[{"Name": "free", "Levels": [{"Minutes": 10}]}]`,
+			`not json`,
+		}
+		for _, structure := range invalid {
+			if _, err := LoadBlindStructures(strings.NewReader(structure)); err == nil {
+				t.Errorf("expected an error loading %s", structure)
+			}
+		}
+	})
+
+	t.Run("structures are added from a file, if there is one", func(t *testing.T) {
+		game := NewTexasHoldem(&SpyBlindAlerter{}, tutils.NewStubStorage())
+		path := filepath.Join(t.TempDir(), "blinds.json")
+		tutils.AssertNoError(t, os.WriteFile(path, []byte(`[{"Name": "club", "Levels": [{"Blind": 50}]}]`), 0666))
+
+		tutils.AssertNoError(t, game.AddBlindStructuresFromFile(""))
+		tutils.AssertNoError(t, game.AddBlindStructuresFromFile(path))
+		if got := strings.Join(game.BlindStructureNames(), ","); got != "club,deepstack,standard,turbo" {
+			t.Errorf("got %q, want the club's structure added", got)
+		}
+		if err := game.AddBlindStructuresFromFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+			t.Error("expected an error for a missing file")
+		}
+	})
+
+	t.Run("presets are listed by name", func(t *testing.T) {
+		game := NewTexasHoldem(&SpyBlindAlerter{}, tutils.NewStubStorage())
+
+		got := strings.Join(game.BlindStructureNames(), ",")
+		want := "deepstack,standard,turbo"
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestBlindLevelString(t *testing.T) {
+	cases := []struct {
+		level BlindLevel
+		want  string
+	}{
+		{BlindLevel{Blind: 100}, "Blind is now 100"},
+		{BlindLevel{Blind: 400, Ante: 50}, "Blind is now 400, ante 50"},
+		{BlindLevel{Break: true, Minutes: 10}, "Break for 10 minutes"},
+		{BlindLevel{Break: true}, "Break"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.level.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
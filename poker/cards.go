@@ -0,0 +1,215 @@
+package poker
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// HoleCards is how many cards each player is dealt in Texas Hold'em.
+const HoleCards = 2
+
+var (
+	ErrBadCard          = errors.New("bad card")
+	ErrNotEnoughCards   = errors.New("not enough cards left in the deck")
+	ErrNotEnoughPlayers = errors.New("not enough players to deal to")
+)
+
+type Suit uint8
+
+const (
+	Clubs Suit = iota
+	Diamonds
+	Hearts
+	Spades
+)
+
+// Rank is the value of a card, Two to Ace, so ranks compare the way they play.
+type Rank uint8
+
+const (
+	Two Rank = iota + 2
+	Three
+	Four
+	Five
+	Six
+	Seven
+	Eight
+	Nine
+	Ten
+	Jack
+	Queen
+	King
+	Ace
+)
+
+const (
+	suitLetters = "cdhs"
+	rankLetters = "23456789TJQKA"
+)
+
+// Card is written as its rank and suit, e.g. "Ah" or "Td", and is encoded that
+// way in JSON too.
+type Card struct {
+	Rank Rank
+	Suit Suit
+}
+
+func (s Suit) String() string {
+	if s > Spades {
+		return "?"
+	}
+	return string(suitLetters[s])
+}
+
+func (r Rank) String() string {
+	if r < Two || r > Ace {
+		return "?"
+	}
+	return string(rankLetters[r-Two])
+}
+
+func (c Card) String() string {
+	return c.Rank.String() + c.Suit.String()
+}
+
+func ParseCard(s string) (Card, error) {
+	if len(s) != 2 {
+		return Card{}, fmt.Errorf("%w %q, want a rank and a suit like \"Ah\"", ErrBadCard, s)
+	}
+	rank := strings.IndexByte(rankLetters, strings.ToUpper(s[:1])[0])
+	suit := strings.IndexByte(suitLetters, strings.ToLower(s[1:])[0])
+	if rank < 0 || suit < 0 {
+		return Card{}, fmt.Errorf("%w %q, want a rank and a suit like \"Ah\"", ErrBadCard, s)
+	}
+	return Card{Rank: Two + Rank(rank), Suit: Suit(suit)}, nil
+}
+
+// ParseCards reads cards separated by spaces, e.g. "Ah Kd".
+func ParseCards(s string) ([]Card, error) {
+	var cards []Card
+	for _, field := range strings.Fields(s) {
+		card, err := ParseCard(field)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+func (c Card) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Card) UnmarshalText(text []byte) error {
+	card, err := ParseCard(string(text))
+	if err != nil {
+		return err
+	}
+	*c = card
+	return nil
+}
+
+// Deck is the cards left to deal and the ones burned so far. Cards are dealt
+// from the top, the front of the deck.
+type Deck struct {
+	cards  []Card
+	burned []Card
+}
+
+// NewDeck returns the 52 cards in order, clubs first and twos first.
+func NewDeck() *Deck {
+	d := &Deck{}
+	for suit := Clubs; suit <= Spades; suit++ {
+		for rank := Two; rank <= Ace; rank++ {
+			d.cards = append(d.cards, Card{Rank: rank, Suit: suit})
+		}
+	}
+	return d
+}
+
+// NewShuffledDeck returns a deck shuffled with seed. The same seed always gives
+// the same order, so a hand can be replayed.
+func NewShuffledDeck(seed uint64) *Deck {
+	d := NewDeck()
+	d.Shuffle(rand.New(rand.NewPCG(seed, seed)))
+	return d
+}
+
+// RandomSeed returns a seed for NewShuffledDeck that can't be guessed.
+func RandomSeed() (uint64, error) {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return 0, fmt.Errorf("problem seeding the deck, %v", err)
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+func (d *Deck) Shuffle(r *rand.Rand) {
+	r.Shuffle(len(d.cards), func(i, j int) {
+		d.cards[i], d.cards[j] = d.cards[j], d.cards[i]
+	})
+}
+
+func (d *Deck) Remaining() int {
+	return len(d.cards)
+}
+
+func (d *Deck) Burned() []Card {
+	return d.burned
+}
+
+// Deal takes n cards off the top of the deck.
+func (d *Deck) Deal(n int) ([]Card, error) {
+	if n < 0 || n > len(d.cards) {
+		return nil, fmt.Errorf("%w, want %d, have %d", ErrNotEnoughCards, n, len(d.cards))
+	}
+	cards := make([]Card, n)
+	copy(cards, d.cards[:n])
+	d.cards = d.cards[n:]
+	return cards, nil
+}
+
+// Burn puts the top card aside without showing it.
+func (d *Deck) Burn() error {
+	card, err := d.Deal(1)
+	if err != nil {
+		return err
+	}
+	d.burned = append(d.burned, card[0])
+	return nil
+}
+
+// DealHoleCards deals HoleCards to each player one card at a time, starting
+// with the first player, as around a table.
+func (d *Deck) DealHoleCards(players int) ([][]Card, error) {
+	if players < 2 {
+		return nil, fmt.Errorf("%w, got %d", ErrNotEnoughPlayers, players)
+	}
+	if players*HoleCards > len(d.cards) {
+		return nil, fmt.Errorf("%w, want %d, have %d", ErrNotEnoughCards, players*HoleCards, len(d.cards))
+	}
+
+	hands := make([][]Card, players)
+	for range HoleCards {
+		for i := range hands {
+			card, _ := d.Deal(1)
+			hands[i] = append(hands[i], card[0])
+		}
+	}
+	return hands, nil
+}
+
+// DealCommunity burns a card and deals n to the board: three for the flop, one
+// for the turn and one for the river.
+func (d *Deck) DealCommunity(n int) ([]Card, error) {
+	if n+1 > len(d.cards) {
+		return nil, fmt.Errorf("%w, want %d and a burn card, have %d", ErrNotEnoughCards, n, len(d.cards))
+	}
+	d.Burn()
+	return d.Deal(n)
+}
@@ -0,0 +1,148 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCards(t *testing.T) {
+	t.Run("cards are written as rank and suit", func(t *testing.T) {
+		cases := map[string]Card{
+			"Ah": {Ace, Hearts},
+			"Td": {Ten, Diamonds},
+			"2c": {Two, Clubs},
+			"Ks": {King, Spades},
+		}
+		for text, card := range cases {
+			if card.String() != text {
+				t.Errorf("got %q, want %q", card.String(), text)
+			}
+			got, err := ParseCard(text)
+			if err != nil || got != card {
+				t.Errorf("parsing %q got %v, %v, want %v", text, got, err, card)
+			}
+		}
+	})
+
+	t.Run("parsing ignores case", func(t *testing.T) {
+		got, err := ParseCards("aH tD")
+		if err != nil || !reflect.DeepEqual(got, []Card{{Ace, Hearts}, {Ten, Diamonds}}) {
+			t.Errorf("got %v, %v, want Ah Td", got, err)
+		}
+	})
+
+	for _, bad := range []string{"", "A", "1h", "Ax", "10h"} {
+		t.Run("rejects "+bad, func(t *testing.T) {
+			if _, err := ParseCard(bad); !errors.Is(err, ErrBadCard) {
+				t.Errorf("got %v, want ErrBadCard", err)
+			}
+		})
+	}
+
+	t.Run("cards are JSON strings", func(t *testing.T) {
+		data, err := json.Marshal([]Card{{Ace, Hearts}, {Two, Clubs}})
+		if err != nil || string(data) != `["Ah","2c"]` {
+			t.Fatalf("got %s, %v, want [\"Ah\",\"2c\"]", data, err)
+		}
+
+		var got []Card
+		if err := json.Unmarshal(data, &got); err != nil || !reflect.DeepEqual(got, []Card{{Ace, Hearts}, {Two, Clubs}}) {
+			t.Errorf("got %v, %v", got, err)
+		}
+	})
+}
+
+func TestDeck(t *testing.T) {
+	t.Run("a new deck has every card once", func(t *testing.T) {
+		cards, err := NewDeck().Deal(52)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := map[Card]bool{}
+		for _, c := range cards {
+			if seen[c] {
+				t.Errorf("%v is in the deck twice", c)
+			}
+			seen[c] = true
+		}
+		if len(seen) != 52 {
+			t.Errorf("got %d cards, want 52", len(seen))
+		}
+	})
+
+	t.Run("the same seed shuffles the same way", func(t *testing.T) {
+		first, _ := NewShuffledDeck(42).Deal(52)
+		again, _ := NewShuffledDeck(42).Deal(52)
+		other, _ := NewShuffledDeck(43).Deal(52)
+		ordered, _ := NewDeck().Deal(52)
+
+		if !reflect.DeepEqual(first, again) {
+			t.Error("seed 42 shuffled two different decks")
+		}
+		if reflect.DeepEqual(first, other) || reflect.DeepEqual(first, ordered) {
+			t.Error("expected seed 42 to shuffle differently from seed 43 and the new deck")
+		}
+	})
+
+	t.Run("hole cards go around the table", func(t *testing.T) {
+		deck := NewDeck()
+		hands, err := deck.DealHoleCards(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want, _ := ParseCards("2c 5c")
+		if !reflect.DeepEqual(hands[0], want) {
+			t.Errorf("got first hand %v, want %v", hands[0], want)
+		}
+		want, _ = ParseCards("4c 7c")
+		if !reflect.DeepEqual(hands[2], want) {
+			t.Errorf("got last hand %v, want %v", hands[2], want)
+		}
+		if deck.Remaining() != 46 {
+			t.Errorf("got %d cards left, want 46", deck.Remaining())
+		}
+	})
+
+	t.Run("community cards come after a burn", func(t *testing.T) {
+		deck := NewDeck()
+
+		flop, _ := deck.DealCommunity(3)
+		turn, _ := deck.DealCommunity(1)
+
+		want, _ := ParseCards("3c 4c 5c")
+		if !reflect.DeepEqual(flop, want) {
+			t.Errorf("got flop %v, want %v", flop, want)
+		}
+		if turn[0] != (Card{Seven, Clubs}) {
+			t.Errorf("got turn %v, want 7c", turn[0])
+		}
+		burned, _ := ParseCards("2c 6c")
+		if !reflect.DeepEqual(deck.Burned(), burned) {
+			t.Errorf("got burned %v, want %v", deck.Burned(), burned)
+		}
+	})
+
+	t.Run("running out of cards", func(t *testing.T) {
+		deck := NewDeck()
+		deck.Deal(50)
+
+		if _, err := deck.DealCommunity(2); !errors.Is(err, ErrNotEnoughCards) {
+			t.Errorf("got %v, want ErrNotEnoughCards", err)
+		}
+		if _, err := deck.DealHoleCards(2); !errors.Is(err, ErrNotEnoughCards) {
+			t.Errorf("got %v, want ErrNotEnoughCards", err)
+		}
+		if deck.Remaining() != 2 {
+			t.Errorf("a failed deal took cards, %d left", deck.Remaining())
+		}
+	})
+
+	t.Run("hole cards need two players", func(t *testing.T) {
+		if _, err := NewDeck().DealHoleCards(1); !errors.Is(err, ErrNotEnoughPlayers) {
+			t.Errorf("got %v, want ErrNotEnoughPlayers", err)
+		}
+	})
+}
@@ -6,13 +6,21 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/shortykevich/go-with-tests-app/clock"
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 )
 
 const (
 	BaseTime               = 5
-	NumPlayerPrompt        = "Please enter the number of players: "
-	WrongPlayerInputErrMsg = "Bad value received for number of players, please try again with a number\n"
+	NumPlayerPrompt        = "Please enter the number of players or their names: "
+	WrongPlayerInputErrMsg = "Bad value received for the players, please try again with a number like \"7 turbo\" or names like \"Ruth, Chris; turbo\"\n"
+
+	PauseCommand     = "pause"
+	ResumeCommand    = "resume"
+	NextLevelCommand = "next"
 )
 
 type ScheduledAlert struct {
@@ -27,34 +35,74 @@ type CLI struct {
 }
 
 type SpyBlindAlerter struct {
-	alerts []ScheduledAlert
+	alerts  []ScheduledAlert
+	handles []*SpyAlert
 }
 
-type GameSpy struct {
-	StartCalled       bool
-	StartedCalledWith int
-	BlindAlert        []byte
+type SpyAlert struct {
+	Stopped bool
+}
 
-	FinishedCalled   bool
-	FinishCalledWith string
+// GameSpy records how it was started and finished. It is locked while it does,
+// so tests can check it with the asserts while a server is still using it.
+type GameSpy struct {
+	mu sync.Mutex
+
+	StartCalled          bool
+	StartedCalledWith    int
+	StartedWithStructure string
+	StartedWithNames     []string
+	BlindAlert           []byte
+	StartError           error
+
+	FinishedCalled       bool
+	FinishCalledWith     string
+	FinishedWithPlacings []string
 }
 
-func (g *GameSpy) Start(numberOfPlayers int, to io.Writer) {
+func (g *GameSpy) Start(config GameConfig, to io.Writer) (*Tournament, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.StartError != nil {
+		return nil, g.StartError
+	}
 	g.StartCalled = true
-	g.StartedCalledWith = numberOfPlayers
+	g.StartedCalledWith = config.NumOfPlayers
+	g.StartedWithStructure = config.Structure
+	g.StartedWithNames = config.Names
 	to.Write(g.BlindAlert)
+	return newTournament(nil, clock.Real{}, config, BlindStructure{}, to), nil
 }
 
-func (g *GameSpy) Finish(winner string) {
-	g.FinishCalledWith = winner
+func (g *GameSpy) Finish(tournament *Tournament, placings ...string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tournament.Stop()
+	g.FinishedCalled = true
+	g.FinishedWithPlacings = placings
+	if len(placings) > 0 {
+		g.FinishCalledWith = placings[0]
+	}
+	return nil
 }
 
 func (s ScheduledAlert) String() string {
 	return fmt.Sprintf("%d chips at %v", s.Amount, s.At)
 }
 
-func (s *SpyBlindAlerter) ScheduleAlertAt(at time.Duration, amount int, to io.Writer) {
-	s.alerts = append(s.alerts, ScheduledAlert{at, amount})
+func (s *SpyBlindAlerter) ScheduleAlertAt(at time.Duration, level BlindLevel, to io.Writer) clock.Timer {
+	s.alerts = append(s.alerts, ScheduledAlert{at, level.Blind})
+	handle := &SpyAlert{}
+	s.handles = append(s.handles, handle)
+	return handle
+}
+
+func (a *SpyAlert) Stop() bool {
+	wasPending := !a.Stopped
+	a.Stopped = true
+	return wasPending
 }
 
 func NewCLI(in io.Reader, out io.Writer, game Game) *CLI {
@@ -68,17 +116,44 @@ func NewCLI(in io.Reader, out io.Writer, game Game) *CLI {
 func (c *CLI) PlayPoker() {
 	fmt.Fprint(c.out, NumPlayerPrompt)
 
-	trimmedPrompt := strings.Trim(c.readInput(), "\n")
-	numOfPlayers, err := strconv.Atoi(trimmedPrompt)
+	config, err := ParseGameConfig(c.readInput())
 	if err != nil {
 		fmt.Fprint(c.out, WrongPlayerInputErrMsg)
 		return
 	}
 
-	c.game.Start(numOfPlayers, c.out)
+	tournament, err := c.game.Start(config, c.out)
+	if err != nil {
+		fmt.Fprintf(c.out, "Could not start the game, %v\n", err)
+		return
+	}
+
+	for c.in.Scan() {
+		input := strings.TrimSpace(c.in.Text())
+		if command, player, ok := strings.Cut(input, " "); ok && PlayerCommands[command] != "" {
+			if err := tournament.Record(PlayerCommands[command], player); err != nil {
+				fmt.Fprintf(c.out, "Could not record that, %v\n", err)
+			}
+			continue
+		}
 
-	userInput := c.readInput()
-	c.game.Finish(getTheName(userInput))
+		switch input {
+		case "":
+		case PauseCommand:
+			tournament.Pause()
+		case ResumeCommand:
+			tournament.Resume()
+		case NextLevelCommand:
+			tournament.NextLevel()
+		default:
+			if err := c.game.Finish(tournament, parsePlacings(input)...); err != nil {
+				fmt.Fprintf(c.out, "Could not finish the game, %v\n", err)
+				continue
+			}
+			return
+		}
+	}
+	tournament.Stop()
 }
 
 func (c *CLI) readInput() string {
@@ -100,6 +175,64 @@ func (c *CLI) awaitNumOfPlayersPrompt() int {
 	}
 }
 
-func getTheName(input string) string {
-	return strings.Replace(input, " wins", "", 1)
+func PrintLeague(out io.Writer, league leaguedb.League) {
+	fmt.Fprintf(out, "%-20s %6s %8s %9s\n", "Player", "Wins", "Rating", "Winnings")
+	for _, p := range league {
+		fmt.Fprintf(out, "%-20s %6d %8.1f %9d\n", p.Name, p.Wins, p.CurrentRating(), p.Winnings)
+	}
+}
+
+func PrintStandings(out io.Writer, standings []leaguedb.Standing) {
+	fmt.Fprintf(out, "%-20s %6s %6s %6s\n", "Player", "Points", "Wins", "Games")
+	for _, s := range standings {
+		fmt.Fprintf(out, "%-20s %6d %6d %6d\n", s.Name, s.Points, s.Wins, s.Games)
+	}
+}
+
+// ParseGameConfig reads "{number of players}" optionally followed by the name of
+// a blind structure, e.g. "7 turbo", or the players' names separated by commas
+// with an optional blind structure after a semicolon, e.g. "Ruth, Chris; turbo".
+func ParseGameConfig(input string) (GameConfig, error) {
+	if strings.Contains(input, ",") {
+		return parseNamedGameConfig(input)
+	}
+
+	fields := strings.Fields(input)
+	if len(fields) == 0 || len(fields) > 2 {
+		return GameConfig{}, fmt.Errorf("expected a number of players and an optional blind structure, got %q", input)
+	}
+
+	numOfPlayers, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return GameConfig{}, err
+	}
+
+	config := GameConfig{NumOfPlayers: numOfPlayers}
+	if len(fields) == 2 {
+		config.Structure = fields[1]
+	}
+	return config, nil
+}
+
+func parseNamedGameConfig(input string) (GameConfig, error) {
+	players, structure, _ := strings.Cut(input, ";")
+
+	var config GameConfig
+	for _, name := range strings.Split(players, ",") {
+		config.Names = append(config.Names, strings.TrimSpace(name))
+	}
+	config.NumOfPlayers = len(config.Names)
+	config.Structure = strings.TrimSpace(structure)
+	return config, nil
+}
+
+// parsePlacings reads "{Name} wins" or a comma separated finishing order from
+// first place down, e.g. "Ruth, Chris, Cleo".
+func parsePlacings(input string) []string {
+	var placings []string
+	for _, name := range strings.Split(input, ",") {
+		placings = append(placings, strings.TrimSpace(name))
+	}
+	placings[0] = strings.TrimSuffix(placings[0], " wins")
+	return placings
 }
@@ -2,12 +2,15 @@ package poker
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
 )
 
@@ -92,7 +95,7 @@ func TestBlindAlerter(t *testing.T) {
 		blindAlerter := &SpyBlindAlerter{}
 		game := NewTexasHoldem(blindAlerter, playerStorage)
 
-		game.Start(7, io.Discard)
+		game.Start(GameConfig{NumOfPlayers: 7}, io.Discard)
 
 		cases := []ScheduledAlert{
 			{0 * time.Second, 100},
@@ -128,6 +131,32 @@ func TestBlindAlerter(t *testing.T) {
 		AssertGameNotStarted(t, game)
 	})
 
+	t.Run("it starts the game with the blind structure after the number of players", func(t *testing.T) {
+		in := userInput("6 turbo")
+		game := &GameSpy{}
+
+		cli := NewCLI(in, dummyStdOut, game)
+		cli.PlayPoker()
+
+		AssertGameStartedWith(t, game, 6)
+		AssertGameStartedWithStructure(t, game, "turbo")
+	})
+
+	t.Run("it prints an error when the blind structure is unknown", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		in := userInput("6 glacial", "Chris wins")
+		storage := tutils.NewStubStorage()
+		game := NewTexasHoldem(dummySpyAlerter, storage)
+
+		cli := NewCLI(in, stdout, game)
+		cli.PlayPoker()
+
+		AssertMessagesSentToUser(t, stdout, NumPlayerPrompt, "Could not start the game, unknown blind structure \"glacial\"\n")
+		if len(storage.WinCalls) != 0 {
+			t.Errorf("expected no win to be recorded, got %v", storage.WinCalls)
+		}
+	})
+
 	t.Run("start game with 3 players and finish game with 'Chris' as winner", func(t *testing.T) {
 		game := &GameSpy{}
 		stdout := &bytes.Buffer{}
@@ -160,10 +189,246 @@ func TestGame_Finish(t *testing.T) {
 	game := NewTexasHoldem(dummyBlindAlerter, store)
 	winner := "Ruth"
 
-	game.Finish(winner)
+	tournament, err := game.Start(GameConfig{NumOfPlayers: 5}, io.Discard)
+	tutils.AssertNoError(t, err)
+
+	game.Finish(tournament, winner)
 	tutils.AssertPlayerWin(t, store, winner)
 }
 
+func TestGame_RecordsHistory(t *testing.T) {
+	store := tutils.NewStubStorage()
+	game := NewTexasHoldem(dummyBlindAlerter, store)
+
+	tournament, err := game.Start(GameConfig{NumOfPlayers: 5}, io.Discard)
+	tutils.AssertNoError(t, err)
+	game.Finish(tournament, "Ruth")
+
+	if len(store.Games) != 1 {
+		t.Fatalf("got %d games recorded, want 1", len(store.Games))
+	}
+	got := store.Games[0]
+	if got.Players != 5 || got.Winner != "Ruth" || got.LastBlind != 100 {
+		t.Errorf("recorded wrong game %+v", got)
+	}
+	if got.FinishedAt.Before(got.StartedAt) {
+		t.Errorf("game finished at %v before it started at %v", got.FinishedAt, got.StartedAt)
+	}
+}
+
+func TestGame_FinishingOrder(t *testing.T) {
+	t.Run("records every place", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		game := NewTexasHoldem(dummyBlindAlerter, store)
+
+		cli := NewCLI(userInput("5", "Ruth, Chris, Cleo"), &bytes.Buffer{}, game)
+		cli.PlayPoker()
+
+		tutils.AssertPlayerWin(t, store, "Ruth")
+		want := []string{"Ruth", "Chris", "Cleo"}
+		if got := store.Games[0].Placings(); !reflect.DeepEqual(got, want) {
+			t.Errorf("got placings %v, want %v", got, want)
+		}
+	})
+
+	t.Run("a bad result can be corrected", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		game := NewTexasHoldem(dummyBlindAlerter, store)
+		out := &bytes.Buffer{}
+
+		cli := NewCLI(userInput("5", "Ruth, Chris, Ruth", "Chris wins"), out, game)
+		cli.PlayPoker()
+
+		if !strings.Contains(out.String(), "Ruth finished twice") {
+			t.Errorf("expected the duplicate to be reported, got %q", out.String())
+		}
+		tutils.AssertPlayerWin(t, store, "Chris")
+		if len(store.Games) != 1 {
+			t.Errorf("got %d games recorded, want 1", len(store.Games))
+		}
+	})
+
+	t.Run("finish rejects an empty result", func(t *testing.T) {
+		alerter := &SpyBlindAlerter{}
+		game := NewTexasHoldem(alerter, tutils.NewStubStorage())
+		tournament, _ := game.Start(GameConfig{NumOfPlayers: 5}, io.Discard)
+
+		if err := game.Finish(tournament); !errors.Is(err, ErrBadResult) {
+			t.Errorf("got %v, want ErrBadResult", err)
+		}
+		if alerter.handles[1].Stopped {
+			t.Error("a rejected result shouldn't stop the clock")
+		}
+	})
+}
+
+func TestGame_RegisteredPlayers(t *testing.T) {
+	newStore := func() *tutils.StubStorage {
+		store := tutils.NewStubStorage()
+		store.Scores["Ruth"] = 3
+		return store
+	}
+
+	t.Run("names set the number of players for the blinds", func(t *testing.T) {
+		alerter := &SpyBlindAlerter{}
+		game := NewTexasHoldem(alerter, newStore())
+
+		tournament, err := game.Start(GameConfig{Names: []string{"Ruth", "Chris", "Cleo"}}, io.Discard)
+		tutils.AssertNoError(t, err)
+
+		if tournament.Config().NumOfPlayers != 3 {
+			t.Errorf("got %d players, want 3", tournament.Config().NumOfPlayers)
+		}
+		checkSchedulingCases(t, []ScheduledAlert{{0 * time.Minute, 100}, {8 * time.Minute, 200}}, alerter)
+	})
+
+	t.Run("names are linked to league players", func(t *testing.T) {
+		store := newStore()
+		game := NewTexasHoldem(dummyBlindAlerter, store)
+
+		tournament, _ := game.Start(GameConfig{Names: []string{"ruth", "Chris"}}, io.Discard)
+		tutils.AssertNoError(t, game.Finish(tournament, "RUTH"))
+
+		tutils.AssertPlayerWin(t, store, "Ruth")
+		if got := store.Games[0].Participants; !reflect.DeepEqual(got, []string{"Ruth", "Chris"}) {
+			t.Errorf("got participants %v, want Ruth and Chris", got)
+		}
+	})
+
+	t.Run("finish rejects a winner who wasn't registered", func(t *testing.T) {
+		store := newStore()
+		game := NewTexasHoldem(dummyBlindAlerter, store)
+
+		tournament, _ := game.Start(GameConfig{Names: []string{"Ruth", "Chris"}}, io.Discard)
+		err := game.Finish(tournament, "Cleo")
+
+		if !errors.Is(err, ErrUnregisteredPlayer) || !errors.Is(err, ErrBadResult) {
+			t.Errorf("got %v, want ErrUnregisteredPlayer", err)
+		}
+		if len(store.WinCalls) != 0 {
+			t.Errorf("expected no winner, got %v", store.WinCalls)
+		}
+	})
+
+	t.Run("players can't be registered twice", func(t *testing.T) {
+		game := NewTexasHoldem(dummyBlindAlerter, newStore())
+
+		_, err := game.Start(GameConfig{Names: []string{"Ruth", "ruth"}}, io.Discard)
+		if !errors.Is(err, ErrBadPlayers) {
+			t.Errorf("got %v, want ErrBadPlayers", err)
+		}
+	})
+
+	t.Run("from the CLI", func(t *testing.T) {
+		store := newStore()
+		game := NewTexasHoldem(dummyBlindAlerter, store)
+		out := &bytes.Buffer{}
+
+		cli := NewCLI(userInput("Ruth, Chris, Cleo; turbo", "Bob wins", "Chris, Ruth"), out, game)
+		cli.PlayPoker()
+
+		if !strings.Contains(out.String(), "not registered") {
+			t.Errorf("expected Bob to be rejected, got %q", out.String())
+		}
+		tutils.AssertPlayerWin(t, store, "Chris")
+	})
+}
+
+func TestGame_Payouts(t *testing.T) {
+	t.Run("the prize pool is paid out at finish", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		game := NewTexasHoldem(dummyBlindAlerter, store).WithPayoutStructure(leaguedb.PayoutStructure{70, 30})
+		out := &bytes.Buffer{}
+
+		tournament, _ := game.Start(GameConfig{NumOfPlayers: 4, BuyIn: 20}, out)
+		tournament.Record(leaguedb.EliminationEvent, "Cleo")
+		tournament.Record(leaguedb.RebuyEvent, "Cleo")
+		tutils.AssertNoError(t, game.Finish(tournament, "Ruth", "Chris"))
+
+		AssertMessagesSentToUser(t, out, "Cleo is out, 3 players left, average stack 13333\n",
+			"Cleo rebought, 4 players left, average stack 12500\n",
+			"Prize pool 100\n1. Ruth 70\n2. Chris 30\n")
+
+		got := store.Games[0]
+		want := []leaguedb.Payout{{Place: 1, Player: "Ruth", Amount: 70}, {Place: 2, Player: "Chris", Amount: 30}}
+		if got.BuyIn != 20 || got.PrizePool != 100 || !reflect.DeepEqual(got.Payouts, want) {
+			t.Errorf("got %+v, want a prize pool of 100 paid to Ruth and Chris", got)
+		}
+	})
+
+	t.Run("the game's buy-in is used when a game has none", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		game := NewTexasHoldem(dummyBlindAlerter, store).WithBuyIn(10)
+
+		tournament, _ := game.Start(GameConfig{NumOfPlayers: 3}, io.Discard)
+		game.Finish(tournament, "Ruth")
+
+		want := []leaguedb.Payout{{Place: 1, Player: "Ruth", Amount: 15}, {Place: 2, Amount: 9}, {Place: 3, Amount: 6}}
+		if got := store.Games[0].Payouts; !reflect.DeepEqual(got, want) {
+			t.Errorf("got payouts %v, want %v", got, want)
+		}
+	})
+
+	t.Run("games without a buy-in pay nothing", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		game := NewTexasHoldem(dummyBlindAlerter, store)
+
+		tournament, _ := game.Start(GameConfig{NumOfPlayers: 3}, io.Discard)
+		game.Finish(tournament, "Ruth")
+
+		if got := store.Games[0]; got.PrizePool != 0 || got.Payouts != nil {
+			t.Errorf("got %+v, want no payouts", got)
+		}
+	})
+}
+
+func TestParseGameConfig(t *testing.T) {
+	cases := []struct {
+		input string
+		want  GameConfig
+	}{
+		{"7", GameConfig{NumOfPlayers: 7}},
+		{"7 turbo", GameConfig{NumOfPlayers: 7, Structure: "turbo"}},
+		{"Ruth, Chris", GameConfig{NumOfPlayers: 2, Names: []string{"Ruth", "Chris"}}},
+		{"Ruth, Chris, Cleo; turbo", GameConfig{NumOfPlayers: 3, Names: []string{"Ruth", "Chris", "Cleo"}, Structure: "turbo"}},
+	}
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			got, err := ParseGameConfig(c.input)
+			tutils.AssertNoError(t, err)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPrintStandings(t *testing.T) {
+	out := &bytes.Buffer{}
+	PrintStandings(out, []leaguedb.Standing{{Name: "Ruth", Points: 43, Wins: 1, Games: 2}})
+
+	want := "Player               Points   Wins  Games\n" +
+		"Ruth                     43      1      2\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestPrintLeague(t *testing.T) {
+	out := &bytes.Buffer{}
+	PrintLeague(out, leaguedb.League{
+		{Name: "Chris", Wins: 3, Rating: 1620, Winnings: 450},
+		{Name: "Cleo", Wins: 10},
+	})
+
+	want := "Player                 Wins   Rating  Winnings\n" +
+		"Chris                     3   1620.0       450\n" +
+		"Cleo                     10   1500.0         0\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
 func checkSchedulingCases(t *testing.T, cases []ScheduledAlert, alerter *SpyBlindAlerter) {
 	t.Helper()
 	for i, want := range cases {
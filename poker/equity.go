@@ -0,0 +1,312 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrBadOdds is returned for odds settings out of range, as opposed to cards
+// that can't be dealt.
+var ErrBadOdds = errors.New("bad odds settings")
+
+const (
+	// BoardCards is how many cards the board has by the river.
+	BoardCards = 5
+	// MaxOddsSamples is the most boards CalculateOdds samples.
+	MaxOddsSamples = 1_000_000
+
+	// sampleChunk is how many boards each job samples. Every chunk has its own
+	// random source, so the odds for a seed don't depend on the workers.
+	sampleChunk = 10_000
+)
+
+// OddsConfig is the hole cards of the players still in a hand and the board
+// dealt so far. With no Samples every possible board is dealt out; otherwise
+// that many boards are dealt at random from Seed, or from a random seed when
+// it is zero. The work is spread over Workers goroutines, one per CPU if zero.
+type OddsConfig struct {
+	Hands   [][]Card
+	Board   []Card
+	Samples int
+	Seed    uint64
+	Workers int
+}
+
+// Odds is how often each hand wins or ties over the Boards dealt. Equity is
+// the share of the pot the hand wins on average, counting ties as a split.
+type Odds struct {
+	Boards     int
+	Exhaustive bool
+	Hands      []HandOdds
+}
+
+// HandOdds are percentages.
+type HandOdds struct {
+	Cards  []Card
+	Win    float64
+	Tie    float64
+	Equity float64
+}
+
+// ParseHands reads hands separated by commas, e.g. "Ah Kd, Qs Qc".
+func ParseHands(s string) ([][]Card, error) {
+	var hands [][]Card
+	for _, field := range strings.Split(s, ",") {
+		hand, err := ParseCards(field)
+		if err != nil {
+			return nil, err
+		}
+		hands = append(hands, hand)
+	}
+	return hands, nil
+}
+
+// CalculateOdds deals out the rest of the board to find how often each hand
+// wins.
+func CalculateOdds(config OddsConfig) (Odds, error) {
+	deck, err := validateOdds(config)
+	if err != nil {
+		return Odds{}, err
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var jobs []func(*oddsTally)
+	missing := BoardCards - len(config.Board)
+	exhaustive := config.Samples == 0
+	if exhaustive {
+		jobs = enumerationJobs(deck, missing)
+	} else {
+		seed := config.Seed
+		if seed == 0 {
+			if seed, err = RandomSeed(); err != nil {
+				return Odds{}, err
+			}
+		}
+		jobs = samplingJobs(deck, missing, config.Samples, seed)
+	}
+
+	queue := make(chan func(*oddsTally))
+	tallies := make([]*oddsTally, workers)
+	var wg sync.WaitGroup
+	for i := range tallies {
+		tallies[i] = newOddsTally(config.Hands, config.Board)
+		wg.Add(1)
+		go func(tally *oddsTally) {
+			defer wg.Done()
+			for job := range queue {
+				job(tally)
+			}
+		}(tallies[i])
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	total := newOddsTally(config.Hands, config.Board)
+	for _, tally := range tallies {
+		total.add(tally)
+	}
+	return total.odds(exhaustive), nil
+}
+
+// validateOdds checks every card is only dealt once and returns the cards left
+// in the deck.
+func validateOdds(config OddsConfig) ([]Card, error) {
+	if len(config.Hands) < 2 {
+		return nil, fmt.Errorf("%w, want 2 or more hands, got %d", ErrNotEnoughPlayers, len(config.Hands))
+	}
+	if len(config.Board) > BoardCards {
+		return nil, fmt.Errorf("%w, a board has at most %d cards, got %d", ErrBadHand, BoardCards, len(config.Board))
+	}
+	if config.Samples < 0 || config.Samples > MaxOddsSamples {
+		return nil, fmt.Errorf("%w, want up to %d samples, got %d", ErrBadOdds, MaxOddsSamples, config.Samples)
+	}
+
+	dealt := slices.Clone(config.Board)
+	for i, hand := range config.Hands {
+		if len(hand) != HoleCards {
+			return nil, fmt.Errorf("%w, hand %d has %d cards, want %d", ErrBadHand, i+1, len(hand), HoleCards)
+		}
+		dealt = append(dealt, hand...)
+	}
+	for i, c := range dealt {
+		if c.Rank < Two || c.Rank > Ace || c.Suit > Spades {
+			return nil, fmt.Errorf("%w, %v is not a card", ErrBadHand, c)
+		}
+		if slices.Contains(dealt[:i], c) {
+			return nil, fmt.Errorf("%w, %v was dealt twice", ErrBadHand, c)
+		}
+	}
+
+	var deck []Card
+	for _, c := range NewDeck().cards {
+		if !slices.Contains(dealt, c) {
+			deck = append(deck, c)
+		}
+	}
+	if missing := BoardCards - len(config.Board); len(deck) < missing {
+		return nil, fmt.Errorf("%w, %d hands leave %d cards for the %d the board needs", ErrBadHand, len(config.Hands), len(deck), missing)
+	}
+	return deck, nil
+}
+
+// enumerationJobs deals every board, one job for each first card.
+func enumerationJobs(deck []Card, missing int) []func(*oddsTally) {
+	if missing == 0 {
+		return []func(*oddsTally){func(t *oddsTally) { t.deal(nil) }}
+	}
+
+	var jobs []func(*oddsTally)
+	for first := 0; first <= len(deck)-missing; first++ {
+		jobs = append(jobs, func(t *oddsTally) {
+			cards := make([]Card, 1, missing)
+			cards[0] = deck[first]
+			enumerate(t, deck[first+1:], cards, missing)
+		})
+	}
+	return jobs
+}
+
+func enumerate(t *oddsTally, deck, cards []Card, missing int) {
+	if len(cards) == missing {
+		t.deal(cards)
+		return
+	}
+	for i := 0; i <= len(deck)-(missing-len(cards)); i++ {
+		enumerate(t, deck[i+1:], append(cards, deck[i]), missing)
+	}
+}
+
+// samplingJobs deals samples random boards in chunks.
+func samplingJobs(deck []Card, missing, samples int, seed uint64) []func(*oddsTally) {
+	var jobs []func(*oddsTally)
+	for chunk := 0; chunk*sampleChunk < samples; chunk++ {
+		n := min(sampleChunk, samples-chunk*sampleChunk)
+		jobs = append(jobs, func(t *oddsTally) {
+			r := rand.New(rand.NewPCG(seed, uint64(chunk)))
+			cards := slices.Clone(deck)
+			for range n {
+				for i := range missing {
+					j := i + r.IntN(len(cards)-i)
+					cards[i], cards[j] = cards[j], cards[i]
+				}
+				t.deal(cards[:missing])
+			}
+		})
+	}
+	return jobs
+}
+
+// oddsTally counts the wins and ties of each hand over the boards one worker
+// deals. Equity is counted in shares of split, the lowest common multiple of
+// the ways a pot can be split, so it adds up exactly.
+type oddsTally struct {
+	holes  [][]Card
+	board  []Card
+	split  int64
+	seven  []Card
+	ranks  []HandRank
+	boards int
+	wins   []int
+	ties   []int
+	equity []int64
+}
+
+func newOddsTally(holes [][]Card, board []Card) *oddsTally {
+	split := int64(1)
+	for n := int64(2); n <= int64(len(holes)); n++ {
+		split = lcm(split, n)
+	}
+	return &oddsTally{
+		holes:  holes,
+		board:  board,
+		split:  split,
+		seven:  make([]Card, 0, HoleCards+BoardCards),
+		ranks:  make([]HandRank, len(holes)),
+		wins:   make([]int, len(holes)),
+		ties:   make([]int, len(holes)),
+		equity: make([]int64, len(holes)),
+	}
+}
+
+// deal evaluates every hand on the board completed with cards.
+func (t *oddsTally) deal(cards []Card) {
+	best := HandRank(0)
+	winners := 0
+	for i, hole := range t.holes {
+		t.seven = append(append(append(t.seven[:0], hole...), t.board...), cards...)
+		t.ranks[i], _ = Evaluate(t.seven)
+		switch {
+		case t.ranks[i] > best:
+			best, winners = t.ranks[i], 1
+		case t.ranks[i] == best:
+			winners++
+		}
+	}
+
+	t.boards++
+	for i, rank := range t.ranks {
+		if rank != best {
+			continue
+		}
+		if winners == 1 {
+			t.wins[i]++
+		} else {
+			t.ties[i]++
+		}
+		t.equity[i] += t.split / int64(winners)
+	}
+}
+
+func (t *oddsTally) add(other *oddsTally) {
+	t.boards += other.boards
+	for i := range t.holes {
+		t.wins[i] += other.wins[i]
+		t.ties[i] += other.ties[i]
+		t.equity[i] += other.equity[i]
+	}
+}
+
+func (t *oddsTally) odds(exhaustive bool) Odds {
+	odds := Odds{Boards: t.boards, Exhaustive: exhaustive}
+	for i, hole := range t.holes {
+		odds.Hands = append(odds.Hands, HandOdds{
+			Cards:  hole,
+			Win:    100 * float64(t.wins[i]) / float64(t.boards),
+			Tie:    100 * float64(t.ties[i]) / float64(t.boards),
+			Equity: 100 * float64(t.equity[i]) / float64(t.split) / float64(t.boards),
+		})
+	}
+	return odds
+}
+
+func lcm(a, b int64) int64 {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return a / x * b
+}
+
+func PrintOdds(out io.Writer, odds Odds) {
+	how := "sampled"
+	if odds.Exhaustive {
+		how = "dealt"
+	}
+	fmt.Fprintf(out, "%d boards %s\n", odds.Boards, how)
+	fmt.Fprintf(out, "%-6s %7s %7s %7s\n", "Hand", "Win", "Tie", "Equity")
+	for _, h := range odds.Hands {
+		fmt.Fprintf(out, "%-6s %6.2f%% %6.2f%% %6.2f%%\n", cardsString(h.Cards), h.Win, h.Tie, h.Equity)
+	}
+}
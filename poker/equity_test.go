@@ -0,0 +1,136 @@
+package poker
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
+)
+
+func oddsConfig(t testing.TB, hands, board string) OddsConfig {
+	t.Helper()
+	holes, err := ParseHands(hands)
+	tutils.AssertNoError(t, err)
+	cards, err := ParseCards(board)
+	tutils.AssertNoError(t, err)
+	return OddsConfig{Hands: holes, Board: cards}
+}
+
+func assertOdds(t testing.TB, got Odds, boards int, want ...[3]float64) {
+	t.Helper()
+	if got.Boards != boards {
+		t.Errorf("got %d boards, want %d", got.Boards, boards)
+	}
+	for i, w := range want {
+		h := got.Hands[i]
+		if math.Abs(h.Win-w[0]) > 0.01 || math.Abs(h.Tie-w[1]) > 0.01 || math.Abs(h.Equity-w[2]) > 0.01 {
+			t.Errorf("got %v for hand %d, want win %.2f, tie %.2f and equity %.2f", h, i+1, w[0], w[1], w[2])
+		}
+	}
+}
+
+func TestCalculateOdds(t *testing.T) {
+	t.Run("a finished board has one result", func(t *testing.T) {
+		odds, err := CalculateOdds(oddsConfig(t, "Ah Kd, Qs Qc", "2c 7d 9h Ks 3s"))
+		tutils.AssertNoError(t, err)
+
+		assertOdds(t, odds, 1, [3]float64{100, 0, 100}, [3]float64{0, 0, 0})
+	})
+
+	t.Run("every river is dealt on the turn", func(t *testing.T) {
+		// Ace King needs one of the three Aces or three Kings left in 44 cards.
+		odds, err := CalculateOdds(oddsConfig(t, "Ah Kd, Qs Qc", "2c 7d 9h 3s"))
+		tutils.AssertNoError(t, err)
+
+		if !odds.Exhaustive {
+			t.Error("expected every board to be dealt")
+		}
+		assertOdds(t, odds, 44, [3]float64{600.0 / 44, 0, 600.0 / 44}, [3]float64{3800.0 / 44, 0, 3800.0 / 44})
+	})
+
+	t.Run("ties split the equity", func(t *testing.T) {
+		// Both have the same straight whatever the river, which the Aces can't beat.
+		odds, err := CalculateOdds(oddsConfig(t, "2c 3d, 2h 3s, Ah Ad", "4c 5d 6h Ks"))
+		tutils.AssertNoError(t, err)
+
+		assertOdds(t, odds, 42, [3]float64{0, 100, 50}, [3]float64{0, 100, 50}, [3]float64{0, 0, 0})
+	})
+
+	t.Run("the equity adds up to the pot preflop", func(t *testing.T) {
+		odds, err := CalculateOdds(oddsConfig(t, "Ah Kd, Qs Qc, 7h 7d", ""))
+		tutils.AssertNoError(t, err)
+
+		equity := 0.0
+		for _, h := range odds.Hands {
+			equity += h.Equity
+		}
+		if odds.Boards != 1370754 || math.Abs(equity-100) > 1e-9 {
+			t.Errorf("got %d boards and %f%% equity, want 1370754 and 100%%", odds.Boards, equity)
+		}
+	})
+
+	t.Run("the same seed samples the same boards however many workers", func(t *testing.T) {
+		config := oddsConfig(t, "Ah Kd, Qs Qc", "")
+		config.Samples, config.Seed = 25_000, 42
+
+		config.Workers = 1
+		first, err := CalculateOdds(config)
+		tutils.AssertNoError(t, err)
+		config.Workers = 4
+		again, err := CalculateOdds(config)
+		tutils.AssertNoError(t, err)
+
+		if !reflect.DeepEqual(first, again) {
+			t.Errorf("got %v and %v", first, again)
+		}
+		if first.Exhaustive || first.Boards != 25_000 {
+			t.Errorf("got %d boards, want 25000 sampled", first.Boards)
+		}
+		// Queens are about a 57% favourite.
+		if eq := first.Hands[1].Equity; eq < 55 || eq > 59 {
+			t.Errorf("got %.2f%% equity for Queens, want about 57%%", eq)
+		}
+	})
+
+	cases := []struct {
+		name   string
+		config OddsConfig
+		want   error
+	}{
+		{"one hand", OddsConfig{Hands: [][]Card{{{Ace, Spades}, {King, Spades}}}}, ErrNotEnoughPlayers},
+		{"three hole cards", oddsConfig(t, "Ah Kd Qd, Qs Qc", ""), ErrBadHand},
+		{"six board cards", oddsConfig(t, "Ah Kd, Qs Qc", "2c 3c 4c 5c 6c 7c"), ErrBadHand},
+		{"a card dealt twice", oddsConfig(t, "Ah Kd, Qs Qc", "2c Qs 4c"), ErrBadHand},
+		{"more hands than leave cards for the board", OddsConfig{Hands: slices.Collect(slices.Chunk(NewDeck().cards[:48], HoleCards))}, ErrBadHand},
+		{"more hands than leave cards for sampled boards", OddsConfig{Hands: slices.Collect(slices.Chunk(NewDeck().cards[:48], HoleCards)), Samples: 10}, ErrBadHand},
+		{"too many samples", OddsConfig{Hands: oddsConfig(t, "Ah Kd, Qs Qc", "").Hands, Samples: MaxOddsSamples + 1}, ErrBadOdds},
+		{"negative samples", OddsConfig{Hands: oddsConfig(t, "Ah Kd, Qs Qc", "").Hands, Samples: -1}, ErrBadOdds},
+	}
+	for _, c := range cases {
+		t.Run("refuses "+c.name, func(t *testing.T) {
+			if _, err := CalculateOdds(c.config); !errors.Is(err, c.want) {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+		})
+	}
+
+	t.Run("odds are printed as a table", func(t *testing.T) {
+		out := &strings.Builder{}
+		PrintOdds(out, Odds{Boards: 44, Exhaustive: true, Hands: []HandOdds{
+			{Cards: []Card{{Ace, Hearts}, {King, Diamonds}}, Win: 13.64, Equity: 13.64},
+			{Cards: []Card{{Queen, Spades}, {Queen, Clubs}}, Win: 86.36, Equity: 86.36},
+		}})
+
+		want := "44 boards dealt\n" +
+			"Hand       Win     Tie  Equity\n" +
+			"Ah Kd   13.64%   0.00%  13.64%\n" +
+			"Qs Qc   86.36%   0.00%  86.36%\n"
+		if out.String() != want {
+			t.Errorf("got %q, want %q", out.String(), want)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+)
+
+const DefaultStartingStack = 10000
+
+// Commands recording a player event, followed by the player's name, e.g.
+// "out Ruth".
+const (
+	EliminateCommand = "out"
+	RebuyCommand     = "rebuy"
+	AddOnCommand     = "addon"
+)
+
+var ErrBadEvent = errors.New("bad event")
+
+// PlayerCommands maps the player event commands to the kind of event they
+// record.
+var PlayerCommands = map[string]string{
+	EliminateCommand: leaguedb.EliminationEvent,
+	RebuyCommand:     leaguedb.RebuyEvent,
+	AddOnCommand:     leaguedb.AddOnEvent,
+}
+
+// Field is who is still in a game and the chips they share between them.
+// PrizePool is what everyone paid for those chips.
+type Field struct {
+	Remaining    int
+	Rebuys       int
+	AddOns       int
+	Chips        int
+	AverageStack int
+	PrizePool    int
+}
+
+func (f Field) String() string {
+	return fmt.Sprintf("%d players left, average stack %d", f.Remaining, f.AverageStack)
+}
+
+// EventWriter is implemented by game outputs that want player events as they
+// are rather than as text.
+type EventWriter interface {
+	WriteEvent(event leaguedb.GameEvent, field Field) error
+}
+
+// Record records an elimination, rebuy or add-on for the player and tells the
+// game's output along with the field that is left. A rebuy brings an
+// eliminated player back in; each player gets one add-on.
+func (t *Tournament) Record(kind, player string) error {
+	t.mu.Lock()
+	event, err := t.record(kind, strings.TrimSpace(player))
+	field := t.field()
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return t.writeEvent(event, field)
+}
+
+func (t *Tournament) writeEvent(event leaguedb.GameEvent, field Field) error {
+	if w, ok := t.to.(EventWriter); ok {
+		return w.WriteEvent(event, field)
+	}
+	_, err := fmt.Fprintf(t.to, "%s, %s\n", event, field)
+	return err
+}
+
+func (t *Tournament) record(kind, player string) (leaguedb.GameEvent, error) {
+	if t.stopped {
+		return leaguedb.GameEvent{}, fmt.Errorf("%w, the game is over", ErrBadEvent)
+	}
+	if player == "" {
+		return leaguedb.GameEvent{}, fmt.Errorf("%w, %s needs a player", ErrBadEvent, kind)
+	}
+	if len(t.config.Names) > 0 {
+		name, ok := registeredName(t.config.Names, player)
+		if !ok {
+			return leaguedb.GameEvent{}, fmt.Errorf("%w, %s isn't registered for the game", ErrBadEvent, player)
+		}
+		player = name
+	}
+	if t.hand != nil && !t.hand.Over() {
+		return leaguedb.GameEvent{}, fmt.Errorf("%w, wait for hand %d to finish", ErrBadEvent, t.hand.Number())
+	}
+
+	key := strings.ToLower(player)
+	switch kind {
+	case leaguedb.EliminationEvent:
+		if t.eliminated[key] {
+			return leaguedb.GameEvent{}, fmt.Errorf("%w, %s is already out", ErrBadEvent, player)
+		}
+		if len(t.eliminated) >= t.config.NumOfPlayers-1 {
+			return leaguedb.GameEvent{}, fmt.Errorf("%w, %s is the last player left", ErrBadEvent, player)
+		}
+		t.eliminated[key] = true
+	case leaguedb.RebuyEvent:
+		if !t.eliminated[key] {
+			return leaguedb.GameEvent{}, fmt.Errorf("%w, %s isn't out", ErrBadEvent, player)
+		}
+		delete(t.eliminated, key)
+	case leaguedb.AddOnEvent:
+		if t.eliminated[key] {
+			return leaguedb.GameEvent{}, fmt.Errorf("%w, %s is out", ErrBadEvent, player)
+		}
+		if t.addOns[key] {
+			return leaguedb.GameEvent{}, fmt.Errorf("%w, %s already took an add-on", ErrBadEvent, player)
+		}
+		t.addOns[key] = true
+	default:
+		return leaguedb.GameEvent{}, fmt.Errorf("%w, unknown event %q", ErrBadEvent, kind)
+	}
+	if t.stacks != nil {
+		if kind == leaguedb.EliminationEvent {
+			t.stacks[key] = 0
+		} else {
+			t.stacks[key] += t.config.StartingStack
+		}
+	}
+
+	event := leaguedb.GameEvent{At: t.clock.Now(), Kind: kind, Player: player}
+	t.events = append(t.events, event)
+	return event, nil
+}
+
+// Events returns the player events recorded so far, oldest first.
+func (t *Tournament) Events() []leaguedb.GameEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.events)
+}
+
+func (t *Tournament) Field() Field {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.field()
+}
+
+func (t *Tournament) field() Field {
+	f := Field{Remaining: t.config.NumOfPlayers - len(t.eliminated)}
+	for _, e := range t.events {
+		switch e.Kind {
+		case leaguedb.RebuyEvent:
+			f.Rebuys++
+		case leaguedb.AddOnEvent:
+			f.AddOns++
+		}
+	}
+	buyIns := t.config.NumOfPlayers + f.Rebuys + f.AddOns
+	f.Chips = buyIns * t.config.StartingStack
+	f.PrizePool = buyIns * t.config.BuyIn
+	if f.Remaining > 0 {
+		f.AverageStack = f.Chips / f.Remaining
+	}
+	return f
+}
@@ -0,0 +1,131 @@
+package poker
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
+)
+
+func TestPlayerEvents(t *testing.T) {
+	startedAt := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+
+	startGame := func(t *testing.T, config GameConfig, out io.Writer) (*Tournament, *TexasHoldem, *tutils.StubStorage) {
+		t.Helper()
+		clock := tutils.NewFakeClock(startedAt)
+		store := tutils.NewStubStorage()
+		game := NewTexasHoldem(dummyBlindAlerter, store).WithClock(clock)
+		tournament, err := game.Start(config, out)
+		tutils.AssertNoError(t, err)
+		return tournament, game, store
+	}
+
+	assertField := func(t *testing.T, got, want Field) {
+		t.Helper()
+		if got != want {
+			t.Errorf("got field %+v, want %+v", got, want)
+		}
+	}
+
+	t.Run("eliminations, rebuys and add-ons change the field", func(t *testing.T) {
+		tournament, _, _ := startGame(t, GameConfig{NumOfPlayers: 4, StartingStack: 1000}, io.Discard)
+		assertField(t, tournament.Field(), Field{Remaining: 4, Chips: 4000, AverageStack: 1000})
+
+		tutils.AssertNoError(t, tournament.Record(leaguedb.EliminationEvent, "Ruth"))
+		assertField(t, tournament.Field(), Field{Remaining: 3, Chips: 4000, AverageStack: 1333})
+
+		tutils.AssertNoError(t, tournament.Record(leaguedb.RebuyEvent, "Ruth"))
+		assertField(t, tournament.Field(), Field{Remaining: 4, Rebuys: 1, Chips: 5000, AverageStack: 1250})
+
+		tutils.AssertNoError(t, tournament.Record(leaguedb.AddOnEvent, "Chris"))
+		assertField(t, tournament.Field(), Field{Remaining: 4, Rebuys: 1, AddOns: 1, Chips: 6000, AverageStack: 1500})
+	})
+
+	t.Run("games start with the default stack", func(t *testing.T) {
+		tournament, _, _ := startGame(t, GameConfig{NumOfPlayers: 2}, io.Discard)
+
+		assertField(t, tournament.Field(), Field{Remaining: 2, Chips: 2 * DefaultStartingStack, AverageStack: DefaultStartingStack})
+	})
+
+	t.Run("events are written to the game's output", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		tournament, _, _ := startGame(t, GameConfig{NumOfPlayers: 3, StartingStack: 1000}, out)
+
+		tournament.Record(leaguedb.EliminationEvent, "Ruth")
+
+		AssertMessagesSentToUser(t, out, "Ruth is out, 2 players left, average stack 1500\n")
+	})
+
+	t.Run("bad events are refused", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			events [][2]string
+		}{
+			{"no player", [][2]string{{leaguedb.EliminationEvent, " "}}},
+			{"unknown kind", [][2]string{{"shuffle", "Ruth"}}},
+			{"out twice", [][2]string{{leaguedb.EliminationEvent, "Ruth"}, {leaguedb.EliminationEvent, "ruth"}}},
+			{"last player out", [][2]string{{leaguedb.EliminationEvent, "Ruth"}, {leaguedb.EliminationEvent, "Chris"}}},
+			{"rebuy without going out", [][2]string{{leaguedb.RebuyEvent, "Ruth"}}},
+			{"add-on after going out", [][2]string{{leaguedb.EliminationEvent, "Ruth"}, {leaguedb.AddOnEvent, "Ruth"}}},
+			{"two add-ons", [][2]string{{leaguedb.AddOnEvent, "Ruth"}, {leaguedb.AddOnEvent, "Ruth"}}},
+			{"unregistered player", [][2]string{{leaguedb.EliminationEvent, "Bob"}}},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				tournament, _, _ := startGame(t, GameConfig{Names: []string{"Ruth", "Chris"}}, io.Discard)
+
+				var err error
+				for _, e := range c.events {
+					err = tournament.Record(e[0], e[1])
+				}
+				if !errors.Is(err, ErrBadEvent) {
+					t.Errorf("got %v, want ErrBadEvent", err)
+				}
+			})
+		}
+	})
+
+	t.Run("events are stored with the game", func(t *testing.T) {
+		tournament, game, store := startGame(t, GameConfig{Names: []string{"Ruth", "Chris", "Cleo"}}, io.Discard)
+
+		tournament.Record(leaguedb.EliminationEvent, "cleo")
+		tournament.Record(leaguedb.RebuyEvent, "Cleo")
+		tutils.AssertNoError(t, game.Finish(tournament, "Ruth"))
+
+		want := []leaguedb.GameEvent{
+			{At: startedAt, Kind: leaguedb.EliminationEvent, Player: "Cleo"},
+			{At: startedAt, Kind: leaguedb.RebuyEvent, Player: "Cleo"},
+		}
+		if got := store.Games[0].Events; !reflect.DeepEqual(got, want) {
+			t.Errorf("got events %v, want %v", got, want)
+		}
+
+		if err := tournament.Record(leaguedb.EliminationEvent, "Chris"); !errors.Is(err, ErrBadEvent) {
+			t.Errorf("got %v recording after the game, want ErrBadEvent", err)
+		}
+	})
+
+	t.Run("from the CLI", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		game := NewTexasHoldem(dummyBlindAlerter, store)
+		out := &bytes.Buffer{}
+
+		cli := NewCLI(userInput("Ruth, Chris, Cleo", "out Cleo", "out Bob", "addon Ruth", "Ruth, Chris, Cleo"), out, game)
+		cli.PlayPoker()
+
+		for _, want := range []string{"Cleo is out, 2 players left, average stack 15000", "Could not record that", "Ruth took an add-on"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("expected %q in %q", want, out.String())
+			}
+		}
+		if got := len(store.Games[0].Events); got != 2 {
+			t.Errorf("got %d events stored, want 2", got)
+		}
+	})
+}
@@ -1,40 +1,279 @@
 package poker
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"time"
+	"slices"
+	"strings"
 
+	"github.com/shortykevich/go-with-tests-app/clock"
 	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 )
 
+var (
+	ErrBadResult          = errors.New("bad result")
+	ErrUnregisteredPlayer = fmt.Errorf("%w, player not registered for the game", ErrBadResult)
+	ErrBadPlayers         = errors.New("bad players")
+)
+
 type Game interface {
-	Start(int, io.Writer)
-	Finish(string)
+	Start(GameConfig, io.Writer) (*Tournament, error)
+	Finish(*Tournament, ...string) error
+}
+
+// GameConfig describes a game to start.
+type GameConfig struct {
+	ID            string `json:",omitempty"` // given by a GameRegistry
+	NumOfPlayers  int
+	Structure     string   // DefaultBlindStructure if empty
+	Names         []string // set the number of players, and only they are paid
+	StartingStack int      // DefaultStartingStack if zero, again for each rebuy and add-on
+	BuyIn         int
+	Seed          uint64 // shuffles the hands, a random seed each if zero
 }
 
 type TexasHoldem struct {
-	alerter BlindAlerter
-	storage leaguedb.PlayersStorage
+	alerter    BlindAlerter
+	storage    leaguedb.PlayersStorage
+	structures blindStructures
+	clock      clock.Clock
+	buyIn      int
+	payouts    leaguedb.PayoutStructure
+}
+
+// PayoutWriter is implemented by game outputs that want the payouts as they are
+// rather than as text.
+type PayoutWriter interface {
+	WritePayouts(pool int, payouts []leaguedb.Payout) error
 }
 
 func NewTexasHoldem(alerter BlindAlerter, storage leaguedb.PlayersStorage) *TexasHoldem {
 	return &TexasHoldem{
-		alerter: alerter,
-		storage: storage,
+		alerter:    alerter,
+		storage:    storage,
+		structures: newBlindStructures(PresetBlindStructures()),
+		clock:      clock.Real{},
+		payouts:    leaguedb.DefaultPayoutStructure,
+	}
+}
+
+// WithClock makes the game and its tournaments tell time with c. It should be
+// the same clock the alerter uses.
+func (g *TexasHoldem) WithClock(c clock.Clock) *TexasHoldem {
+	g.clock = c
+	return g
+}
+
+// WithBuyIn sets the buy-in of games started without one.
+func (g *TexasHoldem) WithBuyIn(amount int) *TexasHoldem {
+	g.buyIn = amount
+	return g
+}
+
+// WithPayoutStructure sets how the prize pool of a game is paid out.
+func (g *TexasHoldem) WithPayoutStructure(structure leaguedb.PayoutStructure) *TexasHoldem {
+	g.payouts = structure
+	return g
+}
+
+func (g *TexasHoldem) Clock() clock.Clock {
+	return g.clock
+}
+
+// AddBlindStructures makes the structures available to Start, replacing presets
+// with the same name.
+func (g *TexasHoldem) AddBlindStructures(structures ...BlindStructure) {
+	for _, s := range structures {
+		g.structures[s.Name] = s
+	}
+}
+
+// AddBlindStructuresFromFile adds the structures in the JSON file at path, if
+// there is one.
+func (g *TexasHoldem) AddBlindStructuresFromFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	structures, err := BlindStructuresFromFile(path)
+	if err != nil {
+		return err
 	}
+	g.AddBlindStructures(structures...)
+	return nil
 }
 
-func (g *TexasHoldem) Start(numOfPlayers int, to io.Writer) {
-	blindInc := time.Duration(BaseTime+numOfPlayers) * time.Minute
+func (g *TexasHoldem) BlindStructureNames() []string {
+	return g.structures.names()
+}
 
-	blinds := []int{100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000}
-	blindTime := 0 * time.Second
-	for _, blind := range blinds {
-		g.alerter.ScheduleAlertAt(blindTime, blind, to)
-		blindTime += blindInc
+func (g *TexasHoldem) Start(config GameConfig, to io.Writer) (*Tournament, error) {
+	structure, err := g.structures.get(config.Structure)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Names) > 0 {
+		if config.Names, err = g.linkPlayers(config.Names); err != nil {
+			return nil, err
+		}
+		config.NumOfPlayers = len(config.Names)
 	}
+	if config.StartingStack < 0 || config.BuyIn < 0 {
+		return nil, fmt.Errorf("%w, starting stack of %d chips for %d", ErrBadPlayers, config.StartingStack, config.BuyIn)
+	}
+	if config.StartingStack == 0 {
+		config.StartingStack = DefaultStartingStack
+	}
+	if config.BuyIn == 0 {
+		config.BuyIn = g.buyIn
+	}
+
+	tournament := newTournament(g.alerter, g.clock, config, structure, to)
+	tournament.start()
+	return tournament, nil
 }
 
-func (g *TexasHoldem) Finish(winner string) {
-	g.storage.PostPlayerScore(winner)
+// Finish stops the tournament's clock and records the finishing order, from the
+// winner down as far as it is known, with the payouts of games played for a
+// buy-in. A bad result, or one storage fails to record, leaves the tournament
+// running.
+func (g *TexasHoldem) Finish(tournament *Tournament, placings ...string) error {
+	placings, err := registeredPlacings(tournament.Config().Names, placings)
+	if err != nil {
+		return err
+	}
+	if err := validatePlacings(placings); err != nil {
+		return err
+	}
+	var hands json.RawMessage
+	if history := tournament.HandHistory(); len(history) > 0 {
+		if hands, err = json.Marshal(history); err != nil {
+			return fmt.Errorf("problem recording the hands, %v", err)
+		}
+	}
+
+	record := leaguedb.GameRecord{
+		ID:         tournament.Config().ID,
+		StartedAt:  tournament.StartedAt(),
+		FinishedAt: g.clock.Now(),
+		Players:    tournament.Config().NumOfPlayers,
+		LastBlind:  tournament.Blind(),
+		Winner:     placings[0],
+	}
+	if names := tournament.Config().Names; len(names) > 0 {
+		record.Participants = names
+	}
+	if len(placings) > 1 {
+		record.FinishingOrder = placings
+	}
+	record.Events = tournament.Events()
+	record.Hands = hands
+	if config := tournament.Config(); config.BuyIn > 0 {
+		record.BuyIn = config.BuyIn
+		record.PrizePool = tournament.Field().PrizePool
+		record.Payouts = g.payouts.Pay(record.PrizePool, config.NumOfPlayers, placings)
+	}
+	if err := g.storage.RecordGame(record); err != nil {
+		return fmt.Errorf("problem recording the game, %w", err)
+	}
+
+	tournament.Stop()
+	if record.BuyIn > 0 {
+		writePayouts(tournament.to, record.PrizePool, record.Payouts)
+	}
+	return nil
+}
+
+func writePayouts(to io.Writer, pool int, payouts []leaguedb.Payout) {
+	if w, ok := to.(PayoutWriter); ok {
+		w.WritePayouts(pool, payouts)
+		return
+	}
+	fmt.Fprintf(to, "Prize pool %d\n", pool)
+	for _, p := range payouts {
+		player := p.Player
+		if player == "" {
+			player = "-"
+		}
+		fmt.Fprintf(to, "%d. %s %d\n", p.Place, player, p.Amount)
+	}
+}
+
+// linkPlayers matches the names to players already in the league, ignoring
+// case, so a game for "ruth" is recorded for Ruth. Names new to the league are
+// kept as they are.
+func (g *TexasHoldem) linkPlayers(names []string) ([]string, error) {
+	league, err := g.storage.GetLeagueTable()
+	if err != nil {
+		return nil, fmt.Errorf("problem getting the league, %v", err)
+	}
+
+	linked := make([]string, len(names))
+	seen := map[string]bool{}
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("%w, player %d has no name", ErrBadPlayers, i+1)
+		}
+		for _, p := range league {
+			if strings.EqualFold(p.Name, name) {
+				name = p.Name
+				break
+			}
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("%w, %s is registered twice", ErrBadPlayers, name)
+		}
+		seen[strings.ToLower(name)] = true
+		linked[i] = name
+	}
+	return linked, nil
+}
+
+// registeredPlacings checks every placed player was registered for the game,
+// using the registered spelling of their name. Games started with just a
+// number of players take any names.
+func registeredPlacings(names, placings []string) ([]string, error) {
+	if len(names) == 0 {
+		return placings, nil
+	}
+
+	registered := make([]string, len(placings))
+	for i, placed := range placings {
+		if placed == "" {
+			continue
+		}
+		name, ok := registeredName(names, placed)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnregisteredPlayer, placed)
+		}
+		registered[i] = name
+	}
+	return registered, nil
+}
+
+func registeredName(names []string, name string) (string, bool) {
+	idx := slices.IndexFunc(names, func(registered string) bool { return strings.EqualFold(registered, name) })
+	if idx < 0 {
+		return "", false
+	}
+	return names[idx], true
+}
+
+func validatePlacings(placings []string) error {
+	if len(placings) == 0 {
+		return fmt.Errorf("%w, no winner", ErrBadResult)
+	}
+	seen := map[string]bool{}
+	for i, name := range placings {
+		if name == "" {
+			return fmt.Errorf("%w, place %d has no name", ErrBadResult, i+1)
+		}
+		if seen[name] {
+			return fmt.Errorf("%w, %s finished twice", ErrBadResult, name)
+		}
+		seen[name] = true
+	}
+	return nil
 }
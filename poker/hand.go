@@ -0,0 +1,278 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	MinHandCards = 5
+	MaxHandCards = 7
+)
+
+var ErrBadHand = errors.New("bad hand")
+
+// HandCategory is the kind of hand, from a high card up to a straight flush.
+type HandCategory uint8
+
+const (
+	HighCard HandCategory = iota
+	OnePair
+	TwoPair
+	ThreeOfAKind
+	Straight
+	Flush
+	FullHouse
+	FourOfAKind
+	StraightFlush
+)
+
+var categoryNames = [...]string{
+	"high card",
+	"pair",
+	"two pair",
+	"three of a kind",
+	"straight",
+	"flush",
+	"full house",
+	"four of a kind",
+	"straight flush",
+}
+
+func (c HandCategory) String() string {
+	if int(c) >= len(categoryNames) {
+		return "?"
+	}
+	return categoryNames[c]
+}
+
+// HandRank is the value of the best five cards in a hand. A better hand has a
+// higher rank, and two hands with the same rank split the pot.
+//
+// The category sits above five four-bit ranks, most important first: the
+// quads, trips or pairs, then the kickers.
+type HandRank uint32
+
+const categoryShift = 20
+
+func newHandRank(category HandCategory, ranks ...Rank) HandRank {
+	h := HandRank(category) << categoryShift
+	for i, r := range ranks {
+		h |= HandRank(r) << (16 - 4*i)
+	}
+	return h
+}
+
+func (h HandRank) Category() HandCategory {
+	return HandCategory(h >> categoryShift)
+}
+
+func (h HandRank) rank(i int) Rank {
+	return Rank(h>>(16-4*i)) & 0xF
+}
+
+// String describes the hand the way a dealer would read it out, e.g. "two
+// pair, Aces and Nines".
+func (h HandRank) String() string {
+	first, second := h.rank(0), h.rank(1)
+	switch h.Category() {
+	case HighCard:
+		return "high card " + first.name()
+	case OnePair:
+		return "a pair of " + first.plural()
+	case TwoPair:
+		return fmt.Sprintf("two pair, %s and %s", first.plural(), second.plural())
+	case ThreeOfAKind:
+		return "three of a kind, " + first.plural()
+	case Straight:
+		return "a straight, " + straightName(first)
+	case Flush:
+		return fmt.Sprintf("a flush, %s high", first.name())
+	case FullHouse:
+		return fmt.Sprintf("a full house, %s full of %s", first.plural(), second.plural())
+	case FourOfAKind:
+		return "four of a kind, " + first.plural()
+	case StraightFlush:
+		if first == Ace {
+			return "a royal flush"
+		}
+		return "a straight flush, " + straightName(first)
+	}
+	return "?"
+}
+
+var rankNames = [...]string{"Deuce", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Jack", "Queen", "King", "Ace"}
+
+func (r Rank) name() string {
+	if r < Two || r > Ace {
+		return "?"
+	}
+	return rankNames[r-Two]
+}
+
+func (r Rank) plural() string {
+	if r == Six {
+		return "Sixes"
+	}
+	return r.name() + "s"
+}
+
+func straightName(top Rank) string {
+	if top == Five {
+		return "Ace to Five"
+	}
+	return fmt.Sprintf("%s to %s", (top - 4).name(), top.name())
+}
+
+// Evaluate ranks the best five cards out of 5 to 7.
+func Evaluate(cards []Card) (HandRank, error) {
+	if len(cards) < MinHandCards || len(cards) > MaxHandCards {
+		return 0, fmt.Errorf("%w, want %d to %d cards, got %d", ErrBadHand, MinHandCards, MaxHandCards, len(cards))
+	}
+
+	var (
+		seen   uint64
+		counts [Ace + 1]uint8
+		suits  [Spades + 1]uint16
+		ranks  uint16
+	)
+	for _, c := range cards {
+		if c.Rank < Two || c.Rank > Ace || c.Suit > Spades {
+			return 0, fmt.Errorf("%w, %v is not a card", ErrBadHand, c)
+		}
+		bit := uint64(1) << (uint(c.Suit)*16 + uint(c.Rank))
+		if seen&bit != 0 {
+			return 0, fmt.Errorf("%w, %v is in it twice", ErrBadHand, c)
+		}
+		seen |= bit
+		counts[c.Rank]++
+		suits[c.Suit] |= 1 << c.Rank
+		ranks |= 1 << c.Rank
+	}
+	return evaluate(&counts, &suits, ranks), nil
+}
+
+func evaluate(counts *[Ace + 1]uint8, suits *[Spades + 1]uint16, ranks uint16) HandRank {
+	// With seven cards or fewer a flush leaves too few cards over for quads or
+	// a full house, so the flush can be checked first.
+	for _, suited := range suits {
+		if bits.OnesCount16(suited) >= MinHandCards {
+			if top := straightTop(suited); top != 0 {
+				return newHandRank(StraightFlush, top)
+			}
+			return newHandRank(Flush, topRanks(suited, 5)...)
+		}
+	}
+
+	var quads, trips, pairs uint16
+	for r := Two; r <= Ace; r++ {
+		switch counts[r] {
+		case 4:
+			quads |= 1 << r
+		case 3:
+			trips |= 1 << r
+		case 2:
+			pairs |= 1 << r
+		}
+	}
+
+	switch {
+	case quads != 0:
+		quad := highest(quads)
+		return newHandRank(FourOfAKind, quad, highest(without(ranks, quad)))
+	case trips != 0 && (pairs != 0 || bits.OnesCount16(trips) > 1):
+		three := highest(trips)
+		return newHandRank(FullHouse, three, highest(without(trips, three)|pairs))
+	}
+
+	if top := straightTop(ranks); top != 0 {
+		return newHandRank(Straight, top)
+	}
+
+	switch {
+	case trips != 0:
+		three := highest(trips)
+		return newHandRank(ThreeOfAKind, append([]Rank{three}, topRanks(without(ranks, three), 2)...)...)
+	case bits.OnesCount16(pairs) >= 2:
+		high := highest(pairs)
+		low := highest(without(pairs, high))
+		return newHandRank(TwoPair, high, low, highest(without(without(ranks, high), low)))
+	case pairs != 0:
+		pair := highest(pairs)
+		return newHandRank(OnePair, append([]Rank{pair}, topRanks(without(ranks, pair), 3)...)...)
+	}
+	return newHandRank(HighCard, topRanks(ranks, 5)...)
+}
+
+// straightTop returns the top card of the best straight in a set of ranks, or
+// zero when there isn't one. An ace also counts low, below the two.
+func straightTop(ranks uint16) Rank {
+	if ranks&(1<<Ace) != 0 {
+		ranks |= 1 << (Two - 1)
+	}
+	for top := Ace; top >= Five; top-- {
+		run := uint16(0x1F) << (top - 4)
+		if ranks&run == run {
+			return top
+		}
+	}
+	return 0
+}
+
+func highest(ranks uint16) Rank {
+	return Rank(bits.Len16(ranks) - 1)
+}
+
+func without(ranks uint16, r Rank) uint16 {
+	return ranks &^ (1 << r)
+}
+
+func topRanks(ranks uint16, n int) []Rank {
+	top := make([]Rank, 0, n)
+	for len(top) < n && ranks != 0 {
+		r := highest(ranks)
+		top = append(top, r)
+		ranks = without(ranks, r)
+	}
+	return top
+}
+
+// RankHands ranks each player's hole cards together with the board.
+func RankHands(board []Card, holes [][]Card) ([]HandRank, error) {
+	seen := map[Card]bool{}
+	for _, c := range board {
+		seen[c] = true
+	}
+	ranks := make([]HandRank, len(holes))
+	for i, hole := range holes {
+		for _, c := range hole {
+			if seen[c] {
+				return nil, fmt.Errorf("%w, %v was dealt twice", ErrBadHand, c)
+			}
+			seen[c] = true
+		}
+		rank, err := Evaluate(append(append([]Card{}, hole...), board...))
+		if err != nil {
+			return nil, fmt.Errorf("%w for player %d", err, i+1)
+		}
+		ranks[i] = rank
+	}
+	return ranks, nil
+}
+
+// Winners returns the indexes of the best hands. More than one winner means
+// the pot is split between them.
+func Winners(ranks []HandRank) []int {
+	var winners []int
+	var best HandRank
+	for i, rank := range ranks {
+		switch {
+		case len(winners) == 0 || rank > best:
+			winners, best = []int{i}, rank
+		case rank == best:
+			winners = append(winners, i)
+		}
+	}
+	return winners
+}
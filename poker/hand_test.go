@@ -0,0 +1,207 @@
+package poker
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func mustEvaluate(t testing.TB, cards string) HandRank {
+	t.Helper()
+	hand, err := ParseCards(cards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rank, err := Evaluate(hand)
+	if err != nil {
+		t.Fatalf("could not evaluate %q, %v", cards, err)
+	}
+	return rank
+}
+
+func TestEvaluate(t *testing.T) {
+	cases := []struct {
+		cards    string
+		category HandCategory
+		name     string
+	}{
+		{"Ah Kh Qh Jh Th", StraightFlush, "a royal flush"},
+		{"5d 4d 3d 2d Ad Ks Kh", StraightFlush, "a straight flush, Ace to Five"},
+		{"9c 8c 7c 6c 5c 4c Ah", StraightFlush, "a straight flush, Five to Nine"},
+		{"7s 7h 7d 7c Ah Ks Kd", FourOfAKind, "four of a kind, Sevens"},
+		{"6s 6h 6d Kc Kh 2s", FullHouse, "a full house, Sixes full of Kings"},
+		{"6s 6h 6d Kc Kh Ks 2s", FullHouse, "a full house, Kings full of Sixes"},
+		{"As Ts 7s 4s 2s Ah Ad", Flush, "a flush, Ace high"},
+		{"Kh Qd Js Tc 9h 8h 2c", Straight, "a straight, Nine to King"},
+		{"As 2d 3c 4h 5s Kd Kc", Straight, "a straight, Ace to Five"},
+		{"Qs Kd Ac 2h 3s", HighCard, "high card Ace"},
+		{"2s 2d 2c 9h Js", ThreeOfAKind, "three of a kind, Deuces"},
+		{"As Ad Ks Kd 9h 9c 2s", TwoPair, "two pair, Aces and Kings"},
+		{"Ts Td 8h 7c 4d 3s 2c", OnePair, "a pair of Tens"},
+	}
+	for _, c := range cases {
+		t.Run(c.cards, func(t *testing.T) {
+			got := mustEvaluate(t, c.cards)
+			if got.Category() != c.category || got.String() != c.name {
+				t.Errorf("got %v, %q, want %v, %q", got.Category(), got, c.category, c.name)
+			}
+		})
+	}
+
+	for _, bad := range []string{"Ah Kh Qh Jh", "Ah Kh Qh Jh Th 9h 8h 7h", "Ah Kh Qh Jh Ah"} {
+		t.Run("rejects "+bad, func(t *testing.T) {
+			hand, _ := ParseCards(bad)
+			if _, err := Evaluate(hand); !errors.Is(err, ErrBadHand) {
+				t.Errorf("got %v, want ErrBadHand", err)
+			}
+		})
+	}
+}
+
+func TestHandRankOrder(t *testing.T) {
+	cases := []struct {
+		name          string
+		better, worse string
+	}{
+		{"flush beats straight", "As Ts 7s 4s 2s", "Kh Qd Js Tc 9h"},
+		{"the wheel is the lowest straight", "2s 3d 4c 5h 6s", "As 2d 3c 4h 5s"},
+		{"the higher pair wins", "Qs Qd 5c 4h 2s", "Js Jd Ac Kh Qh"},
+		{"pairs are split on the kickers", "As Ad Kc 9h 3s", "Ac Ah Kd 9s 2h"},
+		{"two pair are split on the fifth card", "Ks Kd 5c 5h Ts", "Kc Kh 5s 5d 9h"},
+		{"the best two of three pairs play", "As Ad 4c 4h 3s 3d 2c", "Ks Kd Qc Qh 3h 3c Jd"},
+		{"full houses go by the trips", "3s 3d 3c 2h 2s", "2c 2d 2h Ah As"},
+		{"quads kick with the best card left", "9s 9d 9c 9h Ah 2c 2d", "9s 9d 9c 9h Kh Qc Qd"},
+		{"high cards go all the way down", "As Jd 9c 7h 5s", "Ac Jh 9d 7s 4h"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			better, worse := mustEvaluate(t, c.better), mustEvaluate(t, c.worse)
+			if better <= worse {
+				t.Errorf("got %v (%s) not beating %v (%s)", c.better, better, c.worse, worse)
+			}
+		})
+	}
+
+	t.Run("only the best five cards count", func(t *testing.T) {
+		first := mustEvaluate(t, "Ah Ad Kc Qs Js 3d 2c")
+		second := mustEvaluate(t, "Ac As Kd Qh Jh 5s 4s")
+		if first != second {
+			t.Errorf("got %s and %s, want a split", first, second)
+		}
+	})
+}
+
+func TestWinners(t *testing.T) {
+	board, _ := ParseCards("Ah Kh 7c 7d 2s")
+	hole := func(cards string) []Card {
+		c, _ := ParseCards(cards)
+		return c
+	}
+
+	t.Run("the best hand wins alone", func(t *testing.T) {
+		ranks, err := RankHands(board, [][]Card{hole("Qs Js"), hole("As 3c"), hole("7h 2h")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Winners(ranks); !reflect.DeepEqual(got, []int{2}) {
+			t.Errorf("got winners %v, want the full house", got)
+		}
+	})
+
+	t.Run("playing the board splits the pot", func(t *testing.T) {
+		ranks, _ := RankHands(board, [][]Card{hole("As 3c"), hole("4c 3d"), hole("Ac 4d")})
+		if got := Winners(ranks); !reflect.DeepEqual(got, []int{0, 2}) {
+			t.Errorf("got winners %v, want the two aces to split", got)
+		}
+	})
+
+	t.Run("a card can't be dealt twice", func(t *testing.T) {
+		if _, err := RankHands(board, [][]Card{hole("As 3c"), hole("As 4d")}); !errors.Is(err, ErrBadHand) {
+			t.Errorf("got %v, want ErrBadHand", err)
+		}
+		if _, err := RankHands(board, [][]Card{hole("Ah 3c")}); !errors.Is(err, ErrBadHand) {
+			t.Errorf("got %v, want ErrBadHand", err)
+		}
+	})
+}
+
+func TestEvaluateEveryFiveCardHand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping every five card hand in short mode")
+	}
+
+	deck, _ := NewDeck().Deal(52)
+	counts := map[HandCategory]int{}
+	distinct := map[HandRank]bool{}
+	hand := make([]Card, 5)
+	for a := 0; a < 52; a++ {
+		for b := a + 1; b < 52; b++ {
+			for c := b + 1; c < 52; c++ {
+				for d := c + 1; d < 52; d++ {
+					for e := d + 1; e < 52; e++ {
+						hand[0], hand[1], hand[2], hand[3], hand[4] = deck[a], deck[b], deck[c], deck[d], deck[e]
+						rank, err := Evaluate(hand)
+						if err != nil {
+							t.Fatal(err)
+						}
+						counts[rank.Category()]++
+						distinct[rank] = true
+					}
+				}
+			}
+		}
+	}
+
+	want := map[HandCategory]int{
+		StraightFlush: 40,
+		FourOfAKind:   624,
+		FullHouse:     3744,
+		Flush:         5108,
+		Straight:      10200,
+		ThreeOfAKind:  54912,
+		TwoPair:       123552,
+		OnePair:       1098240,
+		HighCard:      1302540,
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("got %v, want %v", counts, want)
+	}
+	if len(distinct) != 7462 {
+		t.Errorf("got %d distinct hands, want 7462", len(distinct))
+	}
+}
+
+func TestEvaluateSevenCardsPlaysTheBestFive(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 7))
+	for range 2000 {
+		deck := NewDeck()
+		deck.Shuffle(r)
+		cards, _ := deck.Deal(MaxHandCards)
+
+		var best HandRank
+		for skipA := 0; skipA < len(cards); skipA++ {
+			for skipB := skipA + 1; skipB < len(cards); skipB++ {
+				var five []Card
+				for i, c := range cards {
+					if i != skipA && i != skipB {
+						five = append(five, c)
+					}
+				}
+				rank, _ := Evaluate(five)
+				best = max(best, rank)
+			}
+		}
+
+		if got, _ := Evaluate(cards); got != best {
+			t.Fatalf("got %s for %v, want %s", got, cards, best)
+		}
+	}
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	hand, _ := ParseCards("As Ad Ks Kd 9h 9c 2s")
+	for b.Loop() {
+		Evaluate(hand)
+	}
+}
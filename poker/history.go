@@ -0,0 +1,247 @@
+package poker
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HandHistory is the record of a hand: who was dealt in with what, and
+// everything that happened from the blinds to the pot being won.
+type HandHistory struct {
+	Game       string `json:",omitempty"`
+	Number     int
+	StartedAt  time.Time
+	Button     string
+	SmallBlind int
+	BigBlind   int
+	Ante       int `json:",omitempty"`
+	Seats      []HistorySeat
+	Board      []Card `json:",omitempty"`
+	Actions    []HandAction
+}
+
+// HistorySeat is a player and the stack they started the hand with.
+type HistorySeat struct {
+	Player string
+	Stack  int
+	Cards  []Card `json:",omitempty"`
+}
+
+// History returns the record of the hand so far with everyone's cards in it.
+func (h *Hand) History() HandHistory {
+	history := HandHistory{
+		Number:     h.number,
+		Button:     h.seats[h.button].player,
+		SmallBlind: h.level.Blind / 2,
+		BigBlind:   h.level.Blind,
+		Ante:       h.level.Ante,
+		Board:      slices.Clone(h.board),
+		Actions:    slices.Clone(h.actions),
+	}
+	for _, s := range h.seats {
+		history.Seats = append(history.Seats, HistorySeat{Player: s.player, Stack: s.dealt, Cards: slices.Clone(s.hole)})
+	}
+	return history
+}
+
+// Shown returns the history with only the cards shown at the showdown.
+func (hh HandHistory) Shown() HandHistory {
+	var shown []string
+	for _, a := range hh.Actions {
+		if a.Kind == ShowAction {
+			shown = append(shown, a.Player)
+		}
+	}
+
+	seats := make([]HistorySeat, len(hh.Seats))
+	for i, s := range hh.Seats {
+		seats[i] = HistorySeat{Player: s.Player, Stack: s.Stack}
+		if slices.Contains(shown, s.Player) {
+			seats[i].Cards = s.Cards
+		}
+	}
+	hh.Seats = seats
+	return hh
+}
+
+// WriteHandHistories writes the hands in the text format PokerStars uses,
+// which most hand replayers and trackers can read, with two blank lines
+// between hands.
+func WriteHandHistories(out io.Writer, histories []HandHistory) error {
+	for i, history := range histories {
+		if i > 0 {
+			if _, err := fmt.Fprint(out, "\n\n"); err != nil {
+				return err
+			}
+		}
+		if err := writeHandHistory(out, history); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summaryStreets is when the summary says a player folded on each street.
+var summaryStreets = [...]string{"before Flop", "on the Flop", "on the Turn", "on the River", "on the River"}
+
+func writeHandHistory(out io.Writer, hh HandHistory) error {
+	var b strings.Builder
+	// Hand numbers have the game's ID in front, so hands from different
+	// games don't share one.
+	number := strconv.Itoa(hh.Number)
+	if hh.Game != "" {
+		number = fmt.Sprintf("%s%05d", hh.Game, hh.Number)
+	}
+	fmt.Fprintf(&b, "PokerStars Hand #%s:  Hold'em No Limit (%d/%d) - %s UTC\n", number, hh.SmallBlind, hh.BigBlind, hh.StartedAt.UTC().Format("2006/01/02 15:04:05"))
+	button := slices.IndexFunc(hh.Seats, func(s HistorySeat) bool { return s.Player == hh.Button })
+	fmt.Fprintf(&b, "Table 'Home Game' %d-max Seat #%d is the button\n", len(hh.Seats), button+1)
+	for i, s := range hh.Seats {
+		fmt.Fprintf(&b, "Seat %d: %s (%d in chips)\n", i+1, s.Player, s.Stack)
+	}
+
+	won := map[string]int{}
+	pots := []int{0}
+	for _, a := range hh.Actions {
+		if a.Kind == WinAction {
+			won[a.Player] += a.Amount
+			for len(pots) <= a.Pot {
+				pots = append(pots, 0)
+			}
+			pots[a.Pot] += a.Amount
+		}
+	}
+
+	blinds := map[string]string{}
+	folded := map[string]Street{}
+	bet := map[string]bool{}
+	current := 0
+	var board []Card
+	dealt, showdown := false, false
+	for _, a := range hh.Actions {
+		allIn := ""
+		if a.AllIn {
+			allIn = " and is all-in"
+		}
+		switch a.Kind {
+		case AnteAction:
+			fmt.Fprintf(&b, "%s: posts the ante %d%s\n", a.Player, a.Amount, allIn)
+			continue
+		case SmallBlindAction, BigBlindAction:
+			blinds[a.Player] = strings.ReplaceAll(a.Kind, "_", " ")
+			current = max(current, a.Amount)
+			fmt.Fprintf(&b, "%s: posts %s %d%s\n", a.Player, blinds[a.Player], a.Amount, allIn)
+			continue
+		}
+		if !dealt {
+			dealt = true
+			b.WriteString("*** HOLE CARDS ***\n")
+		}
+
+		switch a.Kind {
+		case CheckAction:
+			fmt.Fprintf(&b, "%s: checks\n", a.Player)
+		case FoldAction:
+			folded[a.Player] = a.Street
+			fmt.Fprintf(&b, "%s: folds\n", a.Player)
+		case CallAction:
+			bet[a.Player] = true
+			fmt.Fprintf(&b, "%s: calls %d%s\n", a.Player, a.Amount, allIn)
+		case BetAction:
+			bet[a.Player], current = true, a.Amount
+			fmt.Fprintf(&b, "%s: bets %d%s\n", a.Player, a.Amount, allIn)
+		case RaiseAction:
+			fmt.Fprintf(&b, "%s: raises %d to %d%s\n", a.Player, a.Amount-current, a.Amount, allIn)
+			bet[a.Player], current = true, a.Amount
+		case ReturnAction:
+			fmt.Fprintf(&b, "Uncalled bet (%d) returned to %s\n", a.Amount, a.Player)
+		case DealAction:
+			current = 0
+			fmt.Fprintf(&b, "*** %s ***", strings.ToUpper(a.Street.String()))
+			if len(board) > 0 {
+				fmt.Fprintf(&b, " [%s]", cardsString(board))
+			}
+			fmt.Fprintf(&b, " [%s]\n", cardsString(a.Cards))
+			board = append(board, a.Cards...)
+		case ShowAction:
+			if !showdown {
+				showdown = true
+				b.WriteString("*** SHOW DOWN ***\n")
+			}
+			fmt.Fprintf(&b, "%s: shows [%s] (%s)\n", a.Player, cardsString(a.Cards), a.Hand)
+		case WinAction:
+			pot := "pot"
+			if len(pots) > 1 {
+				pot = potName(a.Pot)
+			}
+			fmt.Fprintf(&b, "%s collected %d from %s\n", a.Player, a.Amount, pot)
+		}
+	}
+
+	b.WriteString("*** SUMMARY ***\n")
+	total := 0
+	for _, pot := range pots {
+		total += pot
+	}
+	fmt.Fprintf(&b, "Total pot %d", total)
+	for i, pot := range pots {
+		if len(pots) > 1 {
+			name := potName(i)
+			fmt.Fprintf(&b, " %s%s %d.", strings.ToUpper(name[:1]), name[1:], pot)
+		}
+	}
+	b.WriteString(" | Rake 0\n")
+	if len(board) > 0 {
+		fmt.Fprintf(&b, "Board [%s]\n", cardsString(board))
+	}
+
+	for i, s := range hh.Seats {
+		fmt.Fprintf(&b, "Seat %d: %s", i+1, s.Player)
+		if s.Player == hh.Button {
+			b.WriteString(" (button)")
+		}
+		if blind, ok := blinds[s.Player]; ok {
+			fmt.Fprintf(&b, " (%s)", blind)
+		}
+
+		street, hasFolded := folded[s.Player]
+		show, hasShown := shownBy(hh.Actions, s.Player)
+		switch {
+		case hasFolded && street == Preflop && !bet[s.Player] && blinds[s.Player] == "":
+			b.WriteString(" folded before Flop (didn't bet)")
+		case hasFolded:
+			fmt.Fprintf(&b, " folded %s", summaryStreets[street])
+		case hasShown && won[s.Player] > 0:
+			fmt.Fprintf(&b, " showed [%s] and won (%d) with %s", cardsString(show.Cards), won[s.Player], show.Hand)
+		case hasShown:
+			fmt.Fprintf(&b, " showed [%s] and lost with %s", cardsString(show.Cards), show.Hand)
+		case won[s.Player] > 0:
+			fmt.Fprintf(&b, " collected (%d)", won[s.Player])
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// potName is what PokerStars calls the main pot and the side pots.
+func potName(pot int) string {
+	if pot == 0 {
+		return "main pot"
+	}
+	return fmt.Sprintf("side pot-%d", pot)
+}
+
+// shownBy returns the cards the player showed at the showdown, if they did.
+func shownBy(actions []HandAction, player string) (HandAction, bool) {
+	for _, a := range actions {
+		if a.Kind == ShowAction && a.Player == player {
+			return a, true
+		}
+	}
+	return HandAction{}, false
+}
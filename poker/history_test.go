@@ -0,0 +1,139 @@
+package poker
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func playedHand(t testing.TB) *Hand {
+	t.Helper()
+	deck := stackedDeck(t, "Ah Kd 2c As Kc 3d 7h 2s 2h 9c 4s Js 5h 8d")
+	hand := newTestHand(t, "Ann Bob Cat", []int{1000, 1000, 1000}, BlindLevel{Blind: 100, Ante: 10}, deck)
+	play(t, hand,
+		"Ann call", "Bob raise 300", "Cat call", "Ann fold",
+		"Bob check", "Cat bet 200", "Bob call",
+		"Bob check", "Cat check",
+		"Bob check", "Cat check",
+	)
+	return hand
+}
+
+func TestHandHistory(t *testing.T) {
+	t.Run("only the cards shown are kept", func(t *testing.T) {
+		var got []string
+		for _, s := range playedHand(t).History().Shown().Seats {
+			got = append(got, cardsString(s.Cards))
+		}
+		if want := []string{"", "Ah As", "Kd Kc"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("the history is written the way PokerStars writes it", func(t *testing.T) {
+		history := playedHand(t).History().Shown()
+		history.StartedAt = time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+
+		out := &strings.Builder{}
+		if err := WriteHandHistories(out, []HandHistory{history}); err != nil {
+			t.Fatal(err)
+		}
+
+		want := `PokerStars Hand #1:  Hold'em No Limit (50/100) - 2024/05/01 19:00:00 UTC
+Table 'Home Game' 3-max Seat #1 is the button
+Seat 1: Ann (1000 in chips)
+Seat 2: Bob (1000 in chips)
+Seat 3: Cat (1000 in chips)
+Bob: posts the ante 10
+Cat: posts the ante 10
+Ann: posts the ante 10
+Bob: posts small blind 50
+Cat: posts big blind 100
+*** HOLE CARDS ***
+Ann: calls 100
+Bob: raises 200 to 300
+Cat: calls 200
+Ann: folds
+*** FLOP *** [2s 2h 9c]
+Bob: checks
+Cat: bets 200
+Bob: calls 200
+*** TURN *** [2s 2h 9c] [Js]
+Bob: checks
+Cat: checks
+*** RIVER *** [2s 2h 9c Js] [8d]
+Bob: checks
+Cat: checks
+*** SHOW DOWN ***
+Bob: shows [Ah As] (two pair, Aces and Deuces)
+Cat: shows [Kd Kc] (two pair, Kings and Deuces)
+Bob collected 1130 from pot
+*** SUMMARY ***
+Total pot 1130 | Rake 0
+Board [2s 2h 9c Js 8d]
+Seat 1: Ann (button) folded before Flop
+Seat 2: Bob (small blind) showed [Ah As] and won (1130) with two pair, Aces and Deuces
+Seat 3: Cat (big blind) showed [Kd Kc] and lost with two pair, Kings and Deuces
+`
+		if out.String() != want {
+			t.Errorf("got\n%s\nwant\n%s", out, want)
+		}
+	})
+
+	t.Run("side pots and pots won without a showdown", func(t *testing.T) {
+		deck := stackedDeck(t, "Kh 2c Ah Kd 7d As 3s 4d 8c 9h 5s Jc 6s Qs")
+		allIn := newTestHand(t, "Ann Bob Cat", []int{300, 600, 1000}, BlindLevel{Blind: 100}, deck)
+		play(t, allIn, "Ann allin", "Bob allin", "Cat call")
+		folded := newTestHand(t, "Ann Bob Cat", []int{1000, 1000, 1000}, BlindLevel{Blind: 100}, nil)
+		play(t, folded, "Ann raise 300", "Bob fold", "Cat fold")
+
+		out := &strings.Builder{}
+		if err := WriteHandHistories(out, []HandHistory{allIn.History().Shown(), folded.History().Shown()}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, line := range []string{
+			"Ann: raises 200 to 300 and is all-in\n",
+			"Ann collected 900 from main pot\nBob collected 600 from side pot-1\n",
+			"Total pot 1500 Main pot 900. Side pot-1 600. | Rake 0\n",
+			"Seat 3: Cat (big blind) showed [2c 7d] and lost with high card Queen\n\n\n",
+			"Uncalled bet (200) returned to Ann\nAnn collected 250 from pot\n",
+			"Seat 1: Ann (button) collected (250)\nSeat 2: Bob (small blind) folded before Flop\n",
+		} {
+			if !strings.Contains(out.String(), line) {
+				t.Errorf("got\n%s\nwant it to have %q", out, line)
+			}
+		}
+	})
+
+	t.Run("hand numbers have the game's ID in front", func(t *testing.T) {
+		history := playedHand(t).History()
+		history.Game = "3"
+
+		out := &strings.Builder{}
+		if err := WriteHandHistories(out, []HandHistory{history}); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(out.String(), "PokerStars Hand #300001: ") {
+			t.Errorf("got %q, want hand 300001", strings.SplitN(out.String(), "\n", 2)[0])
+		}
+	})
+
+	t.Run("the history round trips through JSON", func(t *testing.T) {
+		history := playedHand(t).History().Shown()
+
+		data, err := json.Marshal(history)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got HandHistory
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, history) {
+			t.Errorf("got %+v, want %+v", got, history)
+		}
+	})
+}
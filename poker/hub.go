@@ -0,0 +1,219 @@
+package poker
+
+import (
+	"io"
+	"sync"
+)
+
+// viewerQueue is how many messages a viewer can fall behind by before it is
+// dropped.
+const viewerQueue = 64
+
+// Hub fans everything written to it out to every viewer that has joined, so
+// any number of screens can follow the same game. Viewers joining mid-game are
+// sent the last blind change straight away. Each viewer is written to from its
+// own goroutine, so a slow one never holds up the game or the other viewers: a
+// viewer that fails a write is dropped, and one that falls too far behind is
+// dropped and closed.
+type Hub struct {
+	mu      sync.Mutex
+	viewers map[io.Writer]*hubViewer
+	level   []byte
+	closed  bool
+	writers sync.WaitGroup
+	done    chan struct{}
+}
+
+type hubViewer struct {
+	out   io.Writer
+	queue chan []byte
+}
+
+func NewHub() *Hub {
+	return &Hub{viewers: map[io.Writer]*hubViewer{}, done: make(chan struct{})}
+}
+
+func (h *Hub) Write(p []byte) (int, error) {
+	return h.write(p, false)
+}
+
+// WriteLevel writes the level's alert and replays it to viewers who join later.
+func (h *Hub) WriteLevel(level BlindLevel) error {
+	_, err := h.write([]byte(level.String()+"\n"), true)
+	return err
+}
+
+// Levels returns a writer for blind changes in another format, which are
+// replayed to viewers who join later in place of WriteLevel's alerts.
+func (h *Hub) Levels() io.Writer {
+	return hubLevels{h}
+}
+
+type hubLevels struct {
+	hub *Hub
+}
+
+func (l hubLevels) Write(p []byte) (int, error) {
+	return l.hub.write(p, true)
+}
+
+func (h *Hub) write(p []byte, level bool) (int, error) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	msg := append([]byte(nil), p...)
+	if level {
+		h.level = msg
+	}
+	var slow []*hubViewer
+	for _, v := range h.viewers {
+		if !h.queue(v, msg) {
+			slow = append(slow, v)
+		}
+	}
+	h.mu.Unlock()
+
+	closeViewers(slow)
+	return len(p), nil
+}
+
+// queue hands the message to the viewer's writer, or drops the viewer when it
+// has fallen too far behind. It must be called with h.mu held.
+func (h *Hub) queue(v *hubViewer, msg []byte) bool {
+	select {
+	case v.queue <- msg:
+		return true
+	default:
+		h.drop(v)
+		return false
+	}
+}
+
+// drop removes the viewer and lets its writer finish what is queued. It must
+// be called with h.mu held.
+func (h *Hub) drop(v *hubViewer) {
+	if h.viewers[v.out] != v {
+		return
+	}
+	delete(h.viewers, v.out)
+	close(v.queue)
+}
+
+// closeViewers disconnects viewers that fell behind, which also unblocks a
+// write they are stuck in.
+func closeViewers(viewers []*hubViewer) {
+	for _, v := range viewers {
+		if c, ok := v.out.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
+
+// Join adds a viewer and reports whether it joined, which it doesn't once the
+// hub is closed.
+func (h *Hub) Join(viewer io.Writer) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	if old, ok := h.viewers[viewer]; ok {
+		h.drop(old)
+	}
+	v := &hubViewer{out: viewer, queue: make(chan []byte, viewerQueue)}
+	if h.level != nil {
+		v.queue <- h.level
+	}
+	h.viewers[viewer] = v
+	h.writers.Add(1)
+	go h.send(v)
+	return true
+}
+
+// send writes everything queued for the viewer until it is dropped.
+func (h *Hub) send(v *hubViewer) {
+	defer h.writers.Done()
+
+	failed := false
+	for msg := range v.queue {
+		if failed {
+			continue
+		}
+		if _, err := v.out.Write(msg); err != nil {
+			failed = true
+			h.mu.Lock()
+			h.drop(v)
+			h.mu.Unlock()
+		}
+	}
+}
+
+// To returns a writer for messages meant only for the viewer, which reach it
+// in order with everything else written to the hub. Writes fail once the
+// viewer has been dropped.
+func (h *Hub) To(viewer io.Writer) io.Writer {
+	return hubViewerWriter{h, viewer}
+}
+
+type hubViewerWriter struct {
+	hub    *Hub
+	viewer io.Writer
+}
+
+func (w hubViewerWriter) Write(p []byte) (int, error) {
+	h := w.hub
+	h.mu.Lock()
+	v, ok := h.viewers[w.viewer]
+	queued := ok && h.queue(v, append([]byte(nil), p...))
+	h.mu.Unlock()
+
+	if !queued {
+		if ok {
+			closeViewers([]*hubViewer{v})
+		}
+		return 0, io.ErrClosedPipe
+	}
+	return len(p), nil
+}
+
+func (h *Hub) Leave(viewer io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if v, ok := h.viewers[viewer]; ok {
+		h.drop(v)
+	}
+}
+
+func (h *Hub) Viewers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.viewers)
+}
+
+// Close drops every viewer, waits for what was already written to reach them
+// and closes Done. Later writes fail.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	for _, v := range h.viewers {
+		h.drop(v)
+	}
+	h.mu.Unlock()
+
+	h.writers.Wait()
+	close(h.done)
+}
+
+// Done is closed when the game the hub belongs to is over.
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
@@ -0,0 +1,166 @@
+package poker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type failingWriter struct {
+	writes *int
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	*w.writes++
+	return 0, errors.New("viewer went away")
+}
+
+// stalledWriter never finishes a write until it is closed.
+type stalledWriter struct {
+	closed chan struct{}
+}
+
+func (w stalledWriter) Write(p []byte) (int, error) {
+	<-w.closed
+	return 0, errors.New("viewer was closed")
+}
+
+func (w stalledWriter) Close() error {
+	close(w.closed)
+	return nil
+}
+
+// steadyWriter tells the test about every write, so it can keep up.
+type steadyWriter struct {
+	out   *bytes.Buffer
+	wrote chan struct{}
+}
+
+func (w steadyWriter) Write(p []byte) (int, error) {
+	defer func() { w.wrote <- struct{}{} }()
+	return w.out.Write(p)
+}
+
+// Viewers are written to in the background, and closing the hub waits for
+// everything written to it to be delivered, so the tests close it before
+// looking at what viewers got.
+func TestHub(t *testing.T) {
+	t.Run("every viewer gets every message", func(t *testing.T) {
+		hub := NewHub()
+		tv, phone := &bytes.Buffer{}, &bytes.Buffer{}
+		hub.Join(tv)
+		hub.Join(phone)
+
+		hub.Write([]byte("Blind is now 100\n"))
+		hub.Write([]byte("Blind is now 200\n"))
+		hub.Close()
+
+		for _, viewer := range []*bytes.Buffer{tv, phone} {
+			assertAlerts(t, viewer, "Blind is now 100\nBlind is now 200\n")
+		}
+	})
+
+	t.Run("viewers joining mid-game get the last blind change", func(t *testing.T) {
+		hub := NewHub()
+		hub.WriteLevel(BlindLevel{Blind: 100})
+		hub.WriteLevel(BlindLevel{Blind: 200})
+		hub.Write([]byte("Cleo was eliminated\n"))
+
+		laptop := &bytes.Buffer{}
+		hub.Join(laptop)
+		hub.Close()
+
+		assertAlerts(t, laptop, BlindLevel{Blind: 200}.String()+"\n")
+	})
+
+	t.Run("blind changes in another format are replayed instead", func(t *testing.T) {
+		hub := NewHub()
+		hub.WriteLevel(BlindLevel{Blind: 100})
+		fmt.Fprint(hub.Levels(), "{\"Blind\":200}")
+		hub.Write([]byte("{\"Event\":\"eliminated\"}"))
+
+		laptop := &bytes.Buffer{}
+		hub.Join(laptop)
+		hub.Close()
+
+		assertAlerts(t, laptop, "{\"Blind\":200}")
+	})
+
+	t.Run("messages for one viewer arrive in order with the rest", func(t *testing.T) {
+		hub := NewHub()
+		tv, phone := &bytes.Buffer{}, &bytes.Buffer{}
+		hub.Join(tv)
+		hub.Join(phone)
+
+		hub.Write([]byte("Blind is now 100\n"))
+		fmt.Fprint(hub.To(tv), "Cleo's cards\n")
+		hub.Write([]byte("Blind is now 200\n"))
+		hub.Close()
+
+		assertAlerts(t, tv, "Blind is now 100\nCleo's cards\nBlind is now 200\n")
+		assertAlerts(t, phone, "Blind is now 100\nBlind is now 200\n")
+		if _, err := fmt.Fprint(hub.To(tv), "Chris' cards\n"); err == nil {
+			t.Error("expected writing to a dropped viewer to fail")
+		}
+	})
+
+	t.Run("viewers that leave or fail are dropped", func(t *testing.T) {
+		hub := NewHub()
+		tv := &bytes.Buffer{}
+		writes := 0
+		hub.Join(tv)
+		hub.Join(failingWriter{&writes})
+
+		hub.Write([]byte("Blind is now 100\n"))
+		hub.Leave(tv)
+		hub.Write([]byte("Blind is now 200\n"))
+		hub.Close()
+
+		assertAlerts(t, tv, "Blind is now 100\n")
+		if writes != 1 {
+			t.Errorf("got %d writes to the failed viewer, want 1", writes)
+		}
+	})
+
+	t.Run("a viewer that falls behind is closed without holding up the rest", func(t *testing.T) {
+		hub := NewHub()
+		stalled := stalledWriter{make(chan struct{})}
+		tv := steadyWriter{&bytes.Buffer{}, make(chan struct{})}
+		hub.Join(stalled)
+		hub.Join(tv)
+
+		want := ""
+		for i := 0; i < viewerQueue+2; i++ {
+			alert := fmt.Sprintf("Blind is now %d\n", i)
+			hub.Write([]byte(alert))
+			<-tv.wrote
+			want += alert
+		}
+
+		select {
+		case <-stalled.closed:
+		default:
+			t.Error("expected the stalled viewer to be closed")
+		}
+		if hub.Viewers() != 1 {
+			t.Errorf("got %d viewers, want only the tv", hub.Viewers())
+		}
+		hub.Close()
+		assertAlerts(t, tv.out, want)
+	})
+
+	t.Run("a closed hub takes no more viewers", func(t *testing.T) {
+		hub := NewHub()
+		hub.Close()
+
+		if hub.Join(&bytes.Buffer{}) {
+			t.Error("expected joining a closed hub to fail")
+		}
+		select {
+		case <-hub.Done():
+		default:
+			t.Error("expected Done to be closed")
+		}
+	})
+}
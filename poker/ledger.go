@@ -0,0 +1,108 @@
+package poker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+)
+
+const (
+	LedgerPrompt  = "Record \"buyin {Name} {amount}\" or \"cashout {Name} {amount}\", or \"settle\" to see who owes whom\n"
+	SettleCommand = "settle"
+)
+
+// LedgerCLI records the buy-ins and cash-outs of a home game session.
+type LedgerCLI struct {
+	in      *bufio.Scanner
+	out     io.Writer
+	ledger  leaguedb.LedgerStorage
+	session string
+}
+
+func NewLedgerCLI(in io.Reader, out io.Writer, ledger leaguedb.LedgerStorage, session string) *LedgerCLI {
+	return &LedgerCLI{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		ledger:  ledger,
+		session: session,
+	}
+}
+
+// Run reads ledger entries until the input ends and then prints the
+// settlement.
+func (c *LedgerCLI) Run() {
+	fmt.Fprint(c.out, LedgerPrompt)
+
+	for c.in.Scan() {
+		switch input := strings.TrimSpace(c.in.Text()); input {
+		case "":
+		case SettleCommand:
+			c.settle()
+		default:
+			entry, err := parseLedgerEntry(input)
+			if err == nil {
+				err = c.ledger.AddLedgerEntry(c.session, entry)
+			}
+			if err != nil {
+				fmt.Fprintf(c.out, "Could not record that, %v\n", err)
+			}
+		}
+	}
+	c.settle()
+}
+
+func (c *LedgerCLI) settle() {
+	sessions, err := c.ledger.GetSessions()
+	if err != nil {
+		fmt.Fprintf(c.out, "Could not get the ledger, %v\n", err)
+		return
+	}
+	for _, s := range sessions {
+		if s.ID == c.session {
+			PrintSettlement(c.out, s.Settle())
+			return
+		}
+	}
+	fmt.Fprintf(c.out, "Could not find session %q\n", c.session)
+}
+
+// parseLedgerEntry reads "{kind} {Name} {amount}", where the name may have
+// spaces in it.
+func parseLedgerEntry(input string) (leaguedb.LedgerEntry, error) {
+	fields := strings.Fields(input)
+	if len(fields) < 3 {
+		return leaguedb.LedgerEntry{}, fmt.Errorf("expected \"{buyin or cashout} {Name} {amount}\", got %q", input)
+	}
+	amount, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return leaguedb.LedgerEntry{}, fmt.Errorf("bad amount %q", fields[len(fields)-1])
+	}
+	return leaguedb.LedgerEntry{
+		Kind:   fields[0],
+		Player: strings.Join(fields[1:len(fields)-1], " "),
+		Amount: amount,
+	}, nil
+}
+
+func PrintSettlement(out io.Writer, settlement leaguedb.Settlement) {
+	fmt.Fprintf(out, "%-20s %6s %6s %6s\n", "Player", "In", "Out", "Net")
+	for _, b := range settlement.Balances {
+		fmt.Fprintf(out, "%-20s %6d %6d %6d\n", b.Player, b.BuyIns, b.CashOuts, b.Net)
+	}
+
+	switch {
+	case settlement.Unaccounted > 0:
+		fmt.Fprintf(out, "%d is still on the table, cash everyone out to settle\n", settlement.Unaccounted)
+	case settlement.Unaccounted < 0:
+		fmt.Fprintf(out, "%d more was cashed out than bought in\n", -settlement.Unaccounted)
+	case len(settlement.Transfers) == 0:
+		fmt.Fprintln(out, "Everyone is square")
+	}
+	for _, t := range settlement.Transfers {
+		fmt.Fprintf(out, "%s pays %s %d\n", t.From, t.To, t.Amount)
+	}
+}
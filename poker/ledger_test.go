@@ -0,0 +1,68 @@
+package poker
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
+)
+
+func TestLedgerCLI(t *testing.T) {
+	t.Run("records entries and prints who owes whom", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		session, _ := store.StartSession(time.Now())
+		out := &bytes.Buffer{}
+
+		cli := NewLedgerCLI(userInput("buyin Ruth Ann 100", "buyin Chris 100", "cashout Chris 170", "cashout Ruth Ann 30"), out, store, session.ID)
+		cli.Run()
+
+		AssertMessagesSentToUser(t, out, LedgerPrompt,
+			"Player                   In    Out    Net\n",
+			"Chris                   100    170     70\n",
+			"Ruth Ann                100     30    -70\n",
+			"Ruth Ann pays Chris 70\n")
+	})
+
+	t.Run("settle before everyone cashed out", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		session, _ := store.StartSession(time.Now())
+		out := &bytes.Buffer{}
+
+		cli := NewLedgerCLI(userInput("buyin Ruth 100", "settle", "cashout Ruth 100"), out, store, session.ID)
+		cli.Run()
+
+		AssertMessagesSentToUser(t, out, LedgerPrompt,
+			"Player                   In    Out    Net\n",
+			"Ruth                    100      0   -100\n",
+			"100 is still on the table, cash everyone out to settle\n",
+			"Player                   In    Out    Net\n",
+			"Ruth                    100    100      0\n",
+			"Everyone is square\n")
+	})
+
+	t.Run("bad entries are refused", func(t *testing.T) {
+		store := tutils.NewStubStorage()
+		session, _ := store.StartSession(time.Now())
+		out := &bytes.Buffer{}
+
+		cli := NewLedgerCLI(userInput("buyin Ruth", "buyin Ruth lots", "tip Ruth 5"), out, store, session.ID)
+		cli.Run()
+
+		if got := bytes.Count(out.Bytes(), []byte("Could not record that")); got != 3 {
+			t.Errorf("got %d refusals in %q, want 3", got, out.String())
+		}
+		sessions, _ := store.GetSessions()
+		if len(sessions[0].Entries) != 0 {
+			t.Errorf("got entries %v, want none", sessions[0].Entries)
+		}
+	})
+}
+
+func TestPrintSettlement(t *testing.T) {
+	out := &bytes.Buffer{}
+	PrintSettlement(out, leaguedb.Settlement{Unaccounted: -20})
+
+	AssertMessagesSentToUser(t, out, "Player                   In    Out    Net\n", "20 more was cashed out than bought in\n")
+}
@@ -0,0 +1,87 @@
+package poker
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Contribution is what a player put into the pot over a hand.
+type Contribution struct {
+	Player string
+	Amount int
+	Folded bool
+}
+
+// Pot is the main pot or a side pot and the players still in who can win it.
+type Pot struct {
+	Amount  int
+	Players []string
+}
+
+// PotShare is what a player wins from one pot, the main pot being pot 0.
+type PotShare struct {
+	Pot    int
+	Player string
+	Amount int
+}
+
+// Pots splits the contributions into the main pot and a side pot for every
+// all-in short of the biggest contribution still in the hand. Each pot can
+// only be won by the players who matched it. Chips folded players put in
+// above that go to the last pot.
+func Pots(contributions []Contribution) []Pot {
+	var caps []int
+	for _, c := range contributions {
+		if !c.Folded && c.Amount > 0 && !slices.Contains(caps, c.Amount) {
+			caps = append(caps, c.Amount)
+		}
+	}
+	slices.Sort(caps)
+
+	var pots []Pot
+	below := 0
+	for i, top := range caps {
+		pot := Pot{}
+		for _, c := range contributions {
+			in := c.Amount
+			if i < len(caps)-1 {
+				in = min(c.Amount, top)
+			}
+			pot.Amount += max(in-below, 0)
+			if !c.Folded && c.Amount >= top {
+				pot.Players = append(pot.Players, c.Player)
+			}
+		}
+		pots = append(pots, pot)
+		below = top
+	}
+	return pots
+}
+
+// SplitPots gives each pot to the best of its players' hands, splitting it
+// between equal hands. Odd chips go to the winners in the order the pot has
+// them, which is the order the contributions were given in; pass them from
+// the left of the button.
+func SplitPots(pots []Pot, ranks map[string]HandRank) ([]PotShare, error) {
+	var shares []PotShare
+	for i, pot := range pots {
+		potRanks := make([]HandRank, len(pot.Players))
+		for j, player := range pot.Players {
+			rank, ok := ranks[player]
+			if !ok {
+				return nil, fmt.Errorf("%w, no hand for %s", ErrBadHand, player)
+			}
+			potRanks[j] = rank
+		}
+
+		winners := Winners(potRanks)
+		for j, w := range winners {
+			share := pot.Amount / len(winners)
+			if j < pot.Amount%len(winners) {
+				share++
+			}
+			shares = append(shares, PotShare{Pot: i, Player: pot.Players[w], Amount: share})
+		}
+	}
+	return shares, nil
+}
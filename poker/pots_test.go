@@ -0,0 +1,119 @@
+package poker
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPots(t *testing.T) {
+	in := func(player string, amount int) Contribution { return Contribution{Player: player, Amount: amount} }
+	folded := func(player string, amount int) Contribution {
+		return Contribution{Player: player, Amount: amount, Folded: true}
+	}
+
+	cases := []struct {
+		name          string
+		contributions []Contribution
+		want          []Pot
+	}{
+		{
+			name:          "everyone in for the same makes one pot",
+			contributions: []Contribution{in("Ann", 100), in("Bob", 100), in("Cat", 100)},
+			want:          []Pot{{300, []string{"Ann", "Bob", "Cat"}}},
+		},
+		{
+			name:          "a short all-in only plays for the main pot",
+			contributions: []Contribution{in("Ann", 50), in("Bob", 200), in("Cat", 200)},
+			want:          []Pot{{150, []string{"Ann", "Bob", "Cat"}}, {300, []string{"Bob", "Cat"}}},
+		},
+		{
+			name:          "every all-in makes another side pot",
+			contributions: []Contribution{in("Ann", 50), in("Bob", 100), in("Cat", 300), in("Dan", 300)},
+			want: []Pot{
+				{200, []string{"Ann", "Bob", "Cat", "Dan"}},
+				{150, []string{"Bob", "Cat", "Dan"}},
+				{400, []string{"Cat", "Dan"}},
+			},
+		},
+		{
+			name:          "all-ins for the same share a pot",
+			contributions: []Contribution{in("Ann", 100), in("Bob", 100), in("Cat", 250)},
+			want:          []Pot{{300, []string{"Ann", "Bob", "Cat"}}, {150, []string{"Cat"}}},
+		},
+		{
+			name:          "folded chips stay in the pots they reached",
+			contributions: []Contribution{folded("Ann", 150), in("Bob", 100), in("Cat", 300)},
+			want:          []Pot{{300, []string{"Bob", "Cat"}}, {250, []string{"Cat"}}},
+		},
+		{
+			name:          "no chips, no pots",
+			contributions: []Contribution{in("Ann", 0), folded("Bob", 0)},
+			want:          nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Pots(c.contributions)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSplitPots(t *testing.T) {
+	aces := newHandRank(OnePair, Ace, King, Queen, Jack)
+	kings := newHandRank(OnePair, King, Ace, Queen, Jack)
+	queens := newHandRank(OnePair, Queen, Ace, King, Jack)
+
+	cases := []struct {
+		name  string
+		pots  []Pot
+		ranks map[string]HandRank
+		want  []PotShare
+	}{
+		{
+			name:  "the best hand takes every pot it is in",
+			pots:  []Pot{{300, []string{"Ann", "Bob", "Cat"}}, {200, []string{"Bob", "Cat"}}},
+			ranks: map[string]HandRank{"Ann": queens, "Bob": aces, "Cat": kings},
+			want:  []PotShare{{0, "Bob", 300}, {1, "Bob", 200}},
+		},
+		{
+			name:  "a short all-in with the best hand only wins the main pot",
+			pots:  []Pot{{300, []string{"Ann", "Bob", "Cat"}}, {200, []string{"Bob", "Cat"}}},
+			ranks: map[string]HandRank{"Ann": aces, "Bob": queens, "Cat": kings},
+			want:  []PotShare{{0, "Ann", 300}, {1, "Cat", 200}},
+		},
+		{
+			name:  "equal hands split a pot with the odd chip to the first",
+			pots:  []Pot{{301, []string{"Ann", "Bob", "Cat"}}, {200, []string{"Bob", "Cat"}}},
+			ranks: map[string]HandRank{"Ann": kings, "Bob": queens, "Cat": kings},
+			want:  []PotShare{{0, "Ann", 151}, {0, "Cat", 150}, {1, "Cat", 200}},
+		},
+		{
+			name:  "ties in every pot",
+			pots:  []Pot{{90, []string{"Ann", "Bob", "Cat"}}, {40, []string{"Bob", "Cat"}}},
+			ranks: map[string]HandRank{"Ann": aces, "Bob": aces, "Cat": aces},
+			want:  []PotShare{{0, "Ann", 30}, {0, "Bob", 30}, {0, "Cat", 30}, {1, "Bob", 20}, {1, "Cat", 20}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := SplitPots(c.pots, c.ranks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+
+	t.Run("every player in a pot needs a hand", func(t *testing.T) {
+		_, err := SplitPots([]Pot{{100, []string{"Ann", "Bob"}}}, map[string]HandRank{"Ann": aces})
+		if !errors.Is(err, ErrBadHand) {
+			t.Errorf("got %v, want ErrBadHand", err)
+		}
+	})
+}
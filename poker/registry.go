@@ -0,0 +1,339 @@
+package poker
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/clock"
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+)
+
+// DefaultResumeWindow is how long a game waits for its host to reconnect
+// before it is abandoned.
+const DefaultResumeWindow = 15 * time.Minute
+
+var (
+	ErrGameNotFound   = errors.New("game not found")
+	ErrUnknownSession = errors.New("unknown session")
+	ErrUnknownSeat    = errors.New("unknown seat")
+)
+
+// GameRegistry keeps track of the tournaments that are running, so several
+// tables can play at once and each can be looked up by its ID.
+type GameRegistry struct {
+	mu           sync.Mutex
+	game         Game
+	clock        clock.Clock
+	output       func(io.Writer) io.Writer
+	resumeWindow time.Duration
+	lastID       int
+	games        map[string]*RunningGame
+}
+
+// RunningGame is a registered game. Its blind alerts go to the Viewers hub.
+// Session is the secret a disconnected host resumes the game with, and Seats
+// has the secret each registered player gets their hole cards with.
+type RunningGame struct {
+	ID         string
+	Session    string
+	Seats      map[string]string
+	Tournament *Tournament
+	Viewers    *Hub
+
+	out     io.Writer
+	hosts   int
+	abandon clock.Timer
+}
+
+// ResultWriter is implemented by game outputs that want the finishing order
+// itself rather than its text.
+type ResultWriter interface {
+	WriteResult(placings []string) error
+}
+
+// GameStatus is a snapshot of a running game.
+type GameStatus struct {
+	ID           string
+	Players      int
+	Structure    string
+	Names        []string `json:",omitempty"`
+	Level        string
+	Blind        int
+	Paused       bool
+	StartedAt    time.Time
+	Remaining    int
+	AverageStack int
+	BuyIn        int        `json:",omitempty"`
+	PrizePool    int        `json:",omitempty"`
+	Hand         *HandState `json:",omitempty"`
+}
+
+// NewGameRegistry runs games of game. Detached games are timed with the game's
+// clock when it has one.
+func NewGameRegistry(game Game) *GameRegistry {
+	r := &GameRegistry{
+		game:         game,
+		clock:        clock.Real{},
+		output:       func(w io.Writer) io.Writer { return w },
+		resumeWindow: DefaultResumeWindow,
+		games:        map[string]*RunningGame{},
+	}
+	if g, ok := game.(interface{ Clock() clock.Clock }); ok {
+		r.clock = g.Clock()
+	}
+	return r
+}
+
+// WithOutput wraps what the games write before it reaches their viewers, for
+// example to encode it for the websocket protocol.
+func (r *GameRegistry) WithOutput(wrap func(io.Writer) io.Writer) *GameRegistry {
+	r.output = wrap
+	return r
+}
+
+// ContinueFrom numbers the games started after the recorded ones, so no two
+// games have the same ID.
+func (r *GameRegistry) ContinueFrom(games []leaguedb.GameRecord) *GameRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, game := range games {
+		if id, err := strconv.Atoi(game.ID); err == nil {
+			r.lastID = max(r.lastID, id)
+		}
+	}
+	return r
+}
+
+// Start starts a game and registers it under a new ID. Join the game's Viewers
+// to follow it.
+func (r *GameRegistry) Start(config GameConfig) (*RunningGame, error) {
+	r.mu.Lock()
+	r.lastID++
+	config.ID = strconv.Itoa(r.lastID)
+	r.mu.Unlock()
+
+	viewers := NewHub()
+	out := r.output(viewers)
+	tournament, err := r.game.Start(config, out)
+	if err != nil {
+		return nil, err
+	}
+	session, err := newSession()
+	if err != nil {
+		tournament.Stop()
+		return nil, err
+	}
+	seats := map[string]string{}
+	for _, name := range tournament.Config().Names {
+		if seats[name], err = newSession(); err != nil {
+			tournament.Stop()
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	running := &RunningGame{ID: config.ID, Session: session, Seats: seats, Tournament: tournament, Viewers: viewers, out: out, hosts: 1}
+	r.games[running.ID] = running
+	return running, nil
+}
+
+func (r *GameRegistry) Get(id string) (*RunningGame, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	running, ok := r.games[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrGameNotFound, id)
+	}
+	return running, nil
+}
+
+// List returns the running games in the order they were started.
+func (r *GameRegistry) List() []*RunningGame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	games := make([]*RunningGame, 0, len(r.games))
+	for _, running := range r.games {
+		games = append(games, running)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		a, _ := strconv.Atoi(games[i].ID)
+		b, _ := strconv.Atoi(games[j].ID)
+		return a < b
+	})
+	return games
+}
+
+// Detach marks a game as having lost a host connection. Once it has none left
+// it keeps running, but is abandoned unless a host resumes it within the resume
+// window.
+func (r *GameRegistry) Detach(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	running, ok := r.games[id]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrGameNotFound, id)
+	}
+	running.hosts--
+	if running.hosts <= 0 && running.abandon == nil {
+		running.abandon = r.clock.AfterFunc(r.resumeWindow, func() { r.abandonDetached(running) })
+	}
+	return nil
+}
+
+// Resume hands a detached game back to the host holding its session.
+func (r *GameRegistry) Resume(session string) (*RunningGame, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, running := range r.games {
+		if subtle.ConstantTimeCompare([]byte(running.Session), []byte(session)) == 1 {
+			running.hosts++
+			if running.abandon != nil {
+				running.abandon.Stop()
+				running.abandon = nil
+			}
+			return running, nil
+		}
+	}
+	return nil, ErrUnknownSession
+}
+
+// abandonDetached abandons a game whose host didn't come back, unless it was
+// resumed in the meantime.
+func (r *GameRegistry) abandonDetached(running *RunningGame) {
+	r.mu.Lock()
+	if running.abandon == nil || r.games[running.ID] != running {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.games, running.ID)
+	running.abandon = nil
+	r.mu.Unlock()
+
+	running.Tournament.Stop()
+	running.Viewers.Close()
+}
+
+// Finish records the finishing order of a running game, tells its viewers, lets
+// them go and forgets it. A game with a bad result stays registered.
+func (r *GameRegistry) Finish(id string, placings ...string) error {
+	running, err := r.remove(id)
+	if err != nil {
+		return err
+	}
+	if err := r.game.Finish(running.Tournament, placings...); err != nil {
+		r.restore(running)
+		return err
+	}
+	if registered, err := registeredPlacings(running.Tournament.Config().Names, placings); err == nil {
+		placings = registered
+	}
+
+	if rw, ok := running.out.(ResultWriter); ok {
+		rw.WriteResult(placings)
+	} else {
+		fmt.Fprintf(running.out, "%s won the game\n", placings[0])
+	}
+	running.Viewers.Close()
+	return nil
+}
+
+// Abandon stops a running game without recording a result.
+func (r *GameRegistry) Abandon(id string) error {
+	running, err := r.remove(id)
+	if err != nil {
+		return err
+	}
+	running.Tournament.Stop()
+	running.Viewers.Close()
+	return nil
+}
+
+func (r *GameRegistry) remove(id string) (*RunningGame, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	running, ok := r.games[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrGameNotFound, id)
+	}
+	delete(r.games, id)
+	if running.abandon != nil {
+		running.abandon.Stop()
+		running.abandon = nil
+	}
+	return running, nil
+}
+
+// restore puts back a game that was removed, waiting for its host again if it
+// had none.
+func (r *GameRegistry) restore(running *RunningGame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.games[running.ID] = running
+	if running.hosts <= 0 {
+		running.abandon = r.clock.AfterFunc(r.resumeWindow, func() { r.abandonDetached(running) })
+	}
+}
+
+func newSession() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("problem creating a session, %v", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// HoleCards returns the player holding the seat and the cards they were dealt
+// in the last hand, so players only ever see their own.
+func (g *RunningGame) HoleCards(seat string) (string, []Card, error) {
+	for player, secret := range g.Seats {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(seat)) == 1 {
+			cards, err := g.Tournament.HoleCards(player)
+			return player, cards, err
+		}
+	}
+	return "", nil, ErrUnknownSeat
+}
+
+func (g *RunningGame) Status() GameStatus {
+	config := g.Tournament.Config()
+	field := g.Tournament.Field()
+	if config.Structure == "" {
+		config.Structure = DefaultBlindStructure
+	}
+	status := GameStatus{
+		ID:        g.ID,
+		Players:   config.NumOfPlayers,
+		Structure: config.Structure,
+		Names:     config.Names,
+		Level:     g.Tournament.Level().String(),
+		Blind:     g.Tournament.Blind(),
+		Paused:    g.Tournament.Paused(),
+		StartedAt: g.Tournament.StartedAt(),
+
+		Remaining:    field.Remaining,
+		AverageStack: field.AverageStack,
+		BuyIn:        config.BuyIn,
+		PrizePool:    field.PrizePool,
+	}
+	if hand, ok := g.Tournament.Hand(); ok {
+		status.Hand = &hand
+	}
+	return status
+}
@@ -0,0 +1,189 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
+)
+
+func TestGameRegistry(t *testing.T) {
+	newRegistry := func() (*GameRegistry, *tutils.StubStorage) {
+		storage := tutils.NewStubStorage()
+		return NewGameRegistry(NewTexasHoldem(&SpyBlindAlerter{}, storage)), storage
+	}
+
+	t.Run("runs several games side by side", func(t *testing.T) {
+		registry, _ := newRegistry()
+
+		first, err := registry.Start(GameConfig{NumOfPlayers: 5})
+		tutils.AssertNoError(t, err)
+		second, err := registry.Start(GameConfig{NumOfPlayers: 8, Structure: "turbo"})
+		tutils.AssertNoError(t, err)
+
+		if first.ID == second.ID {
+			t.Fatalf("both games got the ID %q", first.ID)
+		}
+
+		games := registry.List()
+		if len(games) != 2 || games[0] != first || games[1] != second {
+			t.Fatalf("got games %v, want the first then the second", games)
+		}
+
+		got, err := registry.Get(second.ID)
+		tutils.AssertNoError(t, err)
+		if got.Status().Structure != "turbo" || got.Status().Players != 8 {
+			t.Errorf("got status %+v, want the turbo game for 8", got.Status())
+		}
+	})
+
+	t.Run("finishing a game records it and forgets it", func(t *testing.T) {
+		registry, storage := newRegistry()
+		running, _ := registry.Start(GameConfig{NumOfPlayers: 5})
+
+		tutils.AssertNoError(t, registry.Finish(running.ID, "Ruth"))
+
+		tutils.AssertPlayerWin(t, storage, "Ruth")
+		if _, err := registry.Get(running.ID); !errors.Is(err, ErrGameNotFound) {
+			t.Errorf("got %v, want ErrGameNotFound", err)
+		}
+	})
+
+	t.Run("finished games keep their ID and hands", func(t *testing.T) {
+		registry, storage := newRegistry()
+		running, _ := registry.Start(GameConfig{Names: []string{"Ruth", "Chris"}})
+		tutils.AssertNoError(t, running.Tournament.DealHand())
+		tutils.AssertNoError(t, running.Tournament.Act("Ruth", FoldAction, 0))
+
+		tutils.AssertNoError(t, registry.Finish(running.ID, "Chris"))
+
+		var hands []HandHistory
+		if err := json.Unmarshal(storage.Games[0].Hands, &hands); err != nil {
+			t.Fatal(err)
+		}
+		if storage.Games[0].ID != running.ID || len(hands) != 1 || hands[0].Game != running.ID {
+			t.Errorf("got %+v, want game %s with its hand", storage.Games[0], running.ID)
+		}
+	})
+
+	t.Run("new games are numbered after the recorded ones", func(t *testing.T) {
+		registry, _ := newRegistry()
+		registry.ContinueFrom([]leaguedb.GameRecord{{ID: "7"}, {ID: "12"}, {}})
+
+		running, _ := registry.Start(GameConfig{NumOfPlayers: 5})
+		if running.ID != "13" || running.Tournament.Config().ID != "13" {
+			t.Errorf("got game %q, want 13", running.ID)
+		}
+	})
+
+	t.Run("a game storage can't record stays running", func(t *testing.T) {
+		registry, storage := newRegistry()
+		storage.RecordGameErr = errors.New("disk full")
+		running, _ := registry.Start(GameConfig{NumOfPlayers: 5})
+
+		if err := registry.Finish(running.ID, "Ruth"); err == nil || !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("got %v, want the storage's error", err)
+		}
+		if _, err := registry.Get(running.ID); err != nil || running.Tournament.stopped {
+			t.Errorf("got %v, want the game still running", err)
+		}
+	})
+
+	t.Run("abandoning a game records nothing", func(t *testing.T) {
+		registry, storage := newRegistry()
+		running, _ := registry.Start(GameConfig{NumOfPlayers: 5})
+
+		tutils.AssertNoError(t, registry.Abandon(running.ID))
+
+		if len(storage.WinCalls) != 0 || len(registry.List()) != 0 {
+			t.Errorf("expected no winner and no games, got %v and %v", storage.WinCalls, registry.List())
+		}
+	})
+
+	t.Run("a detached game waits for its host", func(t *testing.T) {
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		storage := tutils.NewStubStorage()
+		registry := NewGameRegistry(NewTexasHoldem(&SpyBlindAlerter{}, storage).WithClock(clock))
+		running, _ := registry.Start(GameConfig{NumOfPlayers: 5})
+
+		tutils.AssertNoError(t, registry.Detach(running.ID))
+		clock.Advance(DefaultResumeWindow - time.Minute)
+
+		resumed, err := registry.Resume(running.Session)
+		tutils.AssertNoError(t, err)
+		if resumed != running {
+			t.Fatalf("got game %q back, want %q", resumed.ID, running.ID)
+		}
+
+		clock.Advance(time.Hour)
+		if _, err := registry.Get(running.ID); err != nil {
+			t.Errorf("a resumed game was abandoned, %v", err)
+		}
+	})
+
+	t.Run("a host reconnecting before the old connection drops", func(t *testing.T) {
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		registry := NewGameRegistry(NewTexasHoldem(&SpyBlindAlerter{}, tutils.NewStubStorage()).WithClock(clock))
+		running, _ := registry.Start(GameConfig{NumOfPlayers: 5})
+
+		registry.Resume(running.Session)
+		registry.Detach(running.ID)
+		clock.Advance(DefaultResumeWindow)
+
+		if _, err := registry.Get(running.ID); err != nil {
+			t.Errorf("the game was abandoned with a host still connected, %v", err)
+		}
+	})
+
+	t.Run("a detached game is abandoned after the resume window", func(t *testing.T) {
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		storage := tutils.NewStubStorage()
+		alerter := &SpyBlindAlerter{}
+		registry := NewGameRegistry(NewTexasHoldem(alerter, storage).WithClock(clock))
+		running, _ := registry.Start(GameConfig{NumOfPlayers: 5})
+
+		registry.Detach(running.ID)
+		clock.Advance(DefaultResumeWindow)
+
+		if _, err := registry.Resume(running.Session); !errors.Is(err, ErrUnknownSession) {
+			t.Errorf("got %v, want ErrUnknownSession", err)
+		}
+		AssertAlertsStopped(t, alerter)
+		if len(storage.WinCalls) != 0 || len(storage.Games) != 0 {
+			t.Errorf("expected nothing recorded, got %v and %v", storage.WinCalls, storage.Games)
+		}
+	})
+
+	t.Run("players get their own hole cards with their seat", func(t *testing.T) {
+		registry, _ := newRegistry()
+		running, _ := registry.Start(GameConfig{Names: []string{"Ruth", "Chris"}, StartingStack: 1000})
+		tutils.AssertNoError(t, running.Tournament.DealHand())
+
+		for _, name := range []string{"Ruth", "Chris"} {
+			player, cards, err := running.HoleCards(running.Seats[name])
+			tutils.AssertNoError(t, err)
+			want, _ := running.Tournament.HoleCards(name)
+			if player != name || cardsString(cards) != cardsString(want) {
+				t.Errorf("got %s with %v, want %s with %v", player, cards, name, want)
+			}
+		}
+		if running.Seats["Ruth"] == running.Seats["Chris"] || running.Seats["Ruth"] == running.Session {
+			t.Errorf("got seats %v and session %q, want every secret different", running.Seats, running.Session)
+		}
+		if _, _, err := running.HoleCards("guess"); !errors.Is(err, ErrUnknownSeat) {
+			t.Errorf("got %v, want ErrUnknownSeat", err)
+		}
+	})
+
+	t.Run("unknown games", func(t *testing.T) {
+		registry, _ := newRegistry()
+
+		if err := registry.Finish("42", "Ruth"); !errors.Is(err, ErrGameNotFound) {
+			t.Errorf("got %v, want ErrGameNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+)
+
+// DealCommand deals the next hand.
+const DealCommand = "deal"
+
+// HandWriter is implemented by game outputs that want what happened in a hand
+// and the table it left rather than text.
+type HandWriter interface {
+	WriteHand(actions []HandAction, state HandState) error
+}
+
+// DealHand deals the next hand to the registered players who have chips, with
+// the button moved on to the next of them. The blinds are the last ones the
+// clock reached, and everyone starts the game with the starting stack.
+func (t *Tournament) DealHand() error {
+	t.mu.Lock()
+	hand, err := t.dealHand()
+	var state HandState
+	if err == nil {
+		state = hand.State()
+	}
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return t.writeHand(hand.Actions(), state)
+}
+
+func (t *Tournament) dealHand() (*Hand, error) {
+	if t.stopped {
+		return nil, fmt.Errorf("%w, the game is over", ErrBadAction)
+	}
+	names := t.config.Names
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w, hands are only dealt to registered players", ErrBadAction)
+	}
+	if t.hand != nil && !t.hand.Over() {
+		return nil, fmt.Errorf("%w, hand %d isn't finished", ErrBadAction, t.hand.Number())
+	}
+	if t.stacks == nil {
+		t.stacks = map[string]int{}
+		for _, name := range names {
+			if !t.eliminated[strings.ToLower(name)] {
+				t.stacks[strings.ToLower(name)] = t.config.StartingStack
+			}
+		}
+	}
+
+	next := -1
+	for n := 1; n <= len(names) && next < 0; n++ {
+		if i := (t.button + n) % len(names); t.stacks[strings.ToLower(names[i])] > 0 {
+			next = i
+		}
+	}
+	config := HandConfig{Number: t.hands + 1, Level: t.blindLevel()}
+	for i, name := range names {
+		if stack := t.stacks[strings.ToLower(name)]; stack > 0 {
+			if i == next {
+				config.Button = len(config.Players)
+			}
+			config.Players = append(config.Players, name)
+			config.Stacks = append(config.Stacks, stack)
+		}
+	}
+
+	seed := t.config.Seed + uint64(config.Number)
+	if t.config.Seed == 0 {
+		var err error
+		if seed, err = RandomSeed(); err != nil {
+			return nil, err
+		}
+	}
+	hand, err := NewHand(config, NewShuffledDeck(seed))
+	if err != nil {
+		return nil, err
+	}
+	t.hands++
+	t.button = next
+	t.hand = hand
+	t.handDealtAt = t.clock.Now()
+	return hand, nil
+}
+
+// Act plays the action of the player whose turn it is in the hand being
+// played. Once the hand is over the players keep what is in front of them, and
+// anyone who has nothing left is out. An elimination that can't be recorded is
+// returned once the hand has been written.
+func (t *Tournament) Act(player, kind string, amount int) error {
+	t.mu.Lock()
+	actions, err := t.act(player, kind, amount)
+	var state HandState
+	var busted []leaguedb.GameEvent
+	var bustErr error
+	if err == nil {
+		state = t.hand.State()
+		if state.Over {
+			busted, bustErr = t.bust()
+		}
+	}
+	field := t.field()
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := t.writeHand(actions, state); err != nil {
+		return err
+	}
+	for _, event := range busted {
+		if err := t.writeEvent(event, field); err != nil {
+			return err
+		}
+	}
+	return bustErr
+}
+
+func (t *Tournament) act(player, kind string, amount int) ([]HandAction, error) {
+	if t.stopped {
+		return nil, fmt.Errorf("%w, the game is over", ErrBadAction)
+	}
+	if t.hand == nil || t.hand.Over() {
+		return nil, fmt.Errorf("%w, no hand is being played, deal one first", ErrBadAction)
+	}
+	actions, err := t.hand.Act(player, kind, amount)
+	if err != nil || !t.hand.Over() {
+		return actions, err
+	}
+
+	history := t.hand.History()
+	history.Game = t.config.ID
+	history.StartedAt = t.handDealtAt
+	t.history = append(t.history, history)
+	return actions, nil
+}
+
+// bust keeps the stacks the hand left and records everyone who has nothing
+// left as out.
+func (t *Tournament) bust() ([]leaguedb.GameEvent, error) {
+	var busted []leaguedb.GameEvent
+	var errs []error
+	stacks := t.hand.Stacks()
+	for i, seat := range t.hand.State().Seats {
+		t.stacks[strings.ToLower(seat.Player)] = stacks[i]
+		if stacks[i] > 0 {
+			continue
+		}
+		event, err := t.record(leaguedb.EliminationEvent, seat.Player)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("problem eliminating %s, %w", seat.Player, err))
+			continue
+		}
+		busted = append(busted, event)
+	}
+	return busted, errors.Join(errs...)
+}
+
+func (t *Tournament) writeHand(actions []HandAction, state HandState) error {
+	if w, ok := t.to.(HandWriter); ok {
+		return w.WriteHand(actions, state)
+	}
+	for _, action := range actions {
+		if _, err := fmt.Fprintln(t.to, action); err != nil {
+			return err
+		}
+	}
+	switch {
+	case state.Over:
+	case state.ToCall > 0:
+		_, err := fmt.Fprintf(t.to, "%s to act, %d to call\n", state.ToAct, state.ToCall)
+		return err
+	default:
+		_, err := fmt.Fprintf(t.to, "%s to act\n", state.ToAct)
+		return err
+	}
+	return nil
+}
+
+// Hand returns the hand being played, or the last one played, and whether a
+// hand has been dealt at all.
+func (t *Tournament) Hand() (HandState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.hand == nil {
+		return HandState{}, false
+	}
+	return t.hand.State(), true
+}
+
+// HandHistory returns the hands that are over with the cards that were shown.
+func (t *Tournament) HandHistory() []HandHistory {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	history := make([]HandHistory, len(t.history))
+	for i, hand := range t.history {
+		history[i] = hand.Shown()
+	}
+	return history
+}
+
+// HoleCards returns the cards the player was dealt in the last hand. They are
+// only for the player to see, and RunningGame.HoleCards hands them out by seat.
+func (t *Tournament) HoleCards(player string) ([]Card, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.hand == nil {
+		return nil, fmt.Errorf("%w, no hand has been dealt", ErrBadAction)
+	}
+	return t.hand.HoleCards(player)
+}
@@ -0,0 +1,179 @@
+package poker
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
+)
+
+func TestTournamentHands(t *testing.T) {
+	startGame := func(t *testing.T, config GameConfig, out io.Writer) (*Tournament, *tutils.FakeClock) {
+		t.Helper()
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		game := NewTexasHoldem(dummyBlindAlerter, tutils.NewStubStorage()).WithClock(clock)
+		tournament, err := game.Start(config, out)
+		tutils.AssertNoError(t, err)
+		return tournament, clock
+	}
+	names := []string{"Ann", "Bob", "Cat"}
+
+	t.Run("hands are dealt with the blinds on the clock", func(t *testing.T) {
+		tournament, clock := startGame(t, GameConfig{Names: names, StartingStack: 1000, Seed: 1}, io.Discard)
+
+		tutils.AssertNoError(t, tournament.DealHand())
+		state, _ := tournament.Hand()
+		if state.BigBlind != 100 || state.Button != "Ann" || state.ToAct != "Ann" {
+			t.Errorf("got %+v, want Ann on the button to act with the big blind at 100", state)
+		}
+
+		tutils.AssertNoError(t, tournament.Act("Ann", FoldAction, 0))
+		tutils.AssertNoError(t, tournament.Act("Bob", FoldAction, 0))
+
+		clock.Advance(15 * time.Minute)
+		tutils.AssertNoError(t, tournament.DealHand())
+		state, _ = tournament.Hand()
+		if state.Number != 2 || state.BigBlind != 200 || state.Button != "Bob" {
+			t.Errorf("got %+v, want hand 2 on Bob's button at 100/200", state)
+		}
+		var stacks []int
+		for _, seat := range state.Seats {
+			stacks = append(stacks, seat.Stack+seat.Bet)
+		}
+		if !reflect.DeepEqual(stacks, []int{1000, 950, 1050}) {
+			t.Errorf("got stacks %v, want the last hand's stacks carried over", stacks)
+		}
+	})
+
+	t.Run("the same seed deals the same cards", func(t *testing.T) {
+		first, _ := startGame(t, GameConfig{Names: names, Seed: 7}, io.Discard)
+		again, _ := startGame(t, GameConfig{Names: names, Seed: 7}, io.Discard)
+		first.DealHand()
+		again.DealHand()
+
+		for _, name := range names {
+			got, _ := first.HoleCards(name)
+			want, _ := again.HoleCards(name)
+			if cardsString(got) != cardsString(want) {
+				t.Errorf("got %v and %v for %s", got, want, name)
+			}
+		}
+	})
+
+	t.Run("players left without chips are out", func(t *testing.T) {
+		tournament, _ := startGame(t, GameConfig{Names: []string{"Ann", "Bob"}, StartingStack: 1000}, io.Discard)
+		tutils.AssertNoError(t, tournament.DealHand())
+		tournament.hand = newTestHand(t, "Ann Bob", []int{1000, 1000}, BlindLevel{Blind: 100}, stackedDeck(t, "Ah 2c Ad 3d 4s 7h 8h 9s 5s Js 6s Kd"))
+
+		tutils.AssertNoError(t, tournament.Act("Ann", AllInAction, 0))
+		tutils.AssertNoError(t, tournament.Act("Bob", CallAction, 0))
+
+		events := tournament.Events()
+		if len(events) != 1 || events[0].Kind != leaguedb.EliminationEvent || events[0].Player != "Ann" {
+			t.Errorf("got events %v, want Ann out", events)
+		}
+		if err := tournament.DealHand(); !errors.Is(err, ErrNotEnoughPlayers) {
+			t.Errorf("got %v dealing to one player, want ErrNotEnoughPlayers", err)
+		}
+	})
+
+	t.Run("an elimination that can't be recorded is returned", func(t *testing.T) {
+		tournament, _ := startGame(t, GameConfig{Names: []string{"Ann", "Bob"}, StartingStack: 1000}, io.Discard)
+		tutils.AssertNoError(t, tournament.DealHand())
+		tournament.hand = newTestHand(t, "Ann Bob", []int{1000, 1000}, BlindLevel{Blind: 100}, stackedDeck(t, "Ah 2c Ad 3d 4s 7h 8h 9s 5s Js 6s Kd"))
+		tournament.eliminated["ann"] = true
+
+		tutils.AssertNoError(t, tournament.Act("Ann", AllInAction, 0))
+		if err := tournament.Act("Bob", CallAction, 0); !errors.Is(err, ErrBadEvent) {
+			t.Errorf("got %v, want ErrBadEvent", err)
+		}
+		if state, _ := tournament.Hand(); !state.Over {
+			t.Error("expected the hand to be over")
+		}
+	})
+
+	t.Run("rebuys bring chips to the table between hands", func(t *testing.T) {
+		tournament, _ := startGame(t, GameConfig{Names: names, StartingStack: 1000}, io.Discard)
+		tutils.AssertNoError(t, tournament.DealHand())
+
+		if err := tournament.Record(leaguedb.AddOnEvent, "Cat"); !errors.Is(err, ErrBadEvent) {
+			t.Errorf("got %v during a hand, want ErrBadEvent", err)
+		}
+		tutils.AssertNoError(t, tournament.Act("Ann", FoldAction, 0))
+		tutils.AssertNoError(t, tournament.Act("Bob", FoldAction, 0))
+		tutils.AssertNoError(t, tournament.Record(leaguedb.AddOnEvent, "Cat"))
+		tutils.AssertNoError(t, tournament.DealHand())
+
+		state, _ := tournament.Hand()
+		if cat := state.Seats[2]; cat.Stack+cat.Bet != 2050 {
+			t.Errorf("got Cat with %d, want 2050", cat.Stack+cat.Bet)
+		}
+	})
+
+	t.Run("hands that are over are kept in the history", func(t *testing.T) {
+		tournament, clock := startGame(t, GameConfig{Names: names, StartingStack: 1000, Seed: 1}, io.Discard)
+		clock.Advance(5 * time.Minute)
+		tutils.AssertNoError(t, tournament.DealHand())
+		dealtAt := clock.Now()
+		tutils.AssertNoError(t, tournament.Act("Ann", FoldAction, 0))
+		tutils.AssertNoError(t, tournament.Act("Bob", FoldAction, 0))
+		tutils.AssertNoError(t, tournament.DealHand())
+
+		history := tournament.HandHistory()
+		if len(history) != 1 || history[0].Number != 1 || !history[0].StartedAt.Equal(dealtAt) {
+			t.Fatalf("got %+v, want only hand 1 dealt at %v", history, dealtAt)
+		}
+		for _, seat := range history[0].Seats {
+			if seat.Cards != nil {
+				t.Errorf("got %s's cards %v, want only shown cards", seat.Player, seat.Cards)
+			}
+		}
+	})
+
+	t.Run("the hand is written to the game's output", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		tournament, _ := startGame(t, GameConfig{Names: names, StartingStack: 1000}, out)
+
+		tournament.DealHand()
+		tournament.Act("Ann", RaiseAction, 300)
+
+		want := "Bob posts the small blind 50\nCat posts the big blind 100\nAnn to act, 100 to call\nAnn raises to 300\nBob to act, 250 to call\n"
+		if out.String() != want {
+			t.Errorf("got %q, want %q", out.String(), want)
+		}
+	})
+
+	cases := []struct {
+		name   string
+		config GameConfig
+		setup  func(*Tournament)
+	}{
+		{"without registered players", GameConfig{NumOfPlayers: 3}, func(*Tournament) {}},
+		{"while a hand is being played", GameConfig{Names: names}, func(t *Tournament) { t.DealHand() }},
+		{"once the game is over", GameConfig{Names: names}, func(t *Tournament) { t.Stop() }},
+	}
+	for _, c := range cases {
+		t.Run("can't deal "+c.name, func(t *testing.T) {
+			tournament, _ := startGame(t, c.config, io.Discard)
+			c.setup(tournament)
+
+			if err := tournament.DealHand(); !errors.Is(err, ErrBadAction) {
+				t.Errorf("got %v, want ErrBadAction", err)
+			}
+		})
+	}
+
+	t.Run("can't act without a hand", func(t *testing.T) {
+		tournament, _ := startGame(t, GameConfig{Names: names}, io.Discard)
+
+		if err := tournament.Act("Ann", CheckAction, 0); err == nil || !strings.Contains(err.Error(), "deal one first") {
+			t.Errorf("got %v, want to be told to deal", err)
+		}
+	})
+}
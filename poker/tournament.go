@@ -0,0 +1,247 @@
+package poker
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/clock"
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+)
+
+// Tournament is a running game returned by Game.Start. It owns the pending blind
+// alerts, so the clock can be paused, resumed, moved on a level or stopped.
+type Tournament struct {
+	mu      sync.Mutex
+	alerter BlindAlerter
+	to      io.Writer
+	clock   clock.Clock
+
+	config    GameConfig
+	levels    []scheduledLevel
+	pending   []clock.Timer
+	startedAt time.Time
+
+	// elapsed is the game time played before runningSince; while paused the
+	// clock doesn't move and runningSince is zero.
+	elapsed      time.Duration
+	runningSince time.Time
+	stopped      bool
+
+	events     []leaguedb.GameEvent
+	eliminated map[string]bool
+	addOns     map[string]bool
+
+	// stacks are the registered players' chips, keyed like eliminated, once
+	// the first hand is dealt. button is the index in the config's Names of
+	// the last hand's dealer. history has the hands that are over, the one
+	// being played was dealt at handDealtAt.
+	stacks      map[string]int
+	button      int
+	hands       int
+	hand        *Hand
+	handDealtAt time.Time
+	history     []HandHistory
+}
+
+type scheduledLevel struct {
+	At    time.Duration
+	Level BlindLevel
+}
+
+func newTournament(alerter BlindAlerter, clk clock.Clock, config GameConfig, structure BlindStructure, to io.Writer) *Tournament {
+	t := &Tournament{
+		alerter: alerter,
+		to:      to,
+		clock:   clk,
+		config:  config,
+
+		eliminated: map[string]bool{},
+		addOns:     map[string]bool{},
+		button:     -1,
+	}
+
+	at := 0 * time.Second
+	for _, level := range structure.Levels {
+		t.levels = append(t.levels, scheduledLevel{at, level})
+		at += level.Duration(config.NumOfPlayers)
+	}
+	return t
+}
+
+func (t *Tournament) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.startedAt = t.clock.Now()
+	t.runningSince = t.startedAt
+	t.schedule(0, true)
+}
+
+// schedule sets up alerts for the levels still to come at gameTime. The level
+// starting exactly at gameTime is only announced when includeCurrent is set, so
+// resuming doesn't repeat the level that is already in play.
+func (t *Tournament) schedule(gameTime time.Duration, includeCurrent bool) {
+	for _, l := range t.levels {
+		if l.At < gameTime || (l.At == gameTime && !includeCurrent) {
+			continue
+		}
+		t.pending = append(t.pending, t.alerter.ScheduleAlertAt(l.At-gameTime, l.Level, t.to))
+	}
+}
+
+func (t *Tournament) cancelPending() {
+	for _, alert := range t.pending {
+		alert.Stop()
+	}
+	t.pending = nil
+}
+
+func (t *Tournament) gameTime() time.Duration {
+	if t.runningSince.IsZero() {
+		return t.elapsed
+	}
+	return t.elapsed + t.clock.Now().Sub(t.runningSince)
+}
+
+func (t *Tournament) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped || t.runningSince.IsZero() {
+		return
+	}
+	t.elapsed = t.gameTime()
+	t.runningSince = time.Time{}
+	t.cancelPending()
+}
+
+func (t *Tournament) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped || !t.runningSince.IsZero() {
+		return
+	}
+	t.runningSince = t.clock.Now()
+	t.schedule(t.elapsed, false)
+}
+
+// NextLevel moves the clock forward to the start of the next level and
+// announces it straight away. A paused clock stays paused.
+func (t *Tournament) NextLevel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+
+	gameTime := t.gameTime()
+	for _, l := range t.levels {
+		if l.At <= gameTime {
+			continue
+		}
+
+		t.cancelPending()
+		t.elapsed = l.At
+		if t.runningSince.IsZero() {
+			t.pending = append(t.pending, t.alerter.ScheduleAlertAt(0, l.Level, t.to))
+			return
+		}
+		t.runningSince = t.clock.Now()
+		t.schedule(t.elapsed, true)
+		return
+	}
+}
+
+// Stop cancels every alert that hasn't fired yet. A stopped tournament can't
+// be resumed.
+func (t *Tournament) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+	t.elapsed = t.gameTime()
+	t.runningSince = time.Time{}
+	t.stopped = true
+	t.cancelPending()
+}
+
+func (t *Tournament) StartedAt() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.startedAt
+}
+
+func (t *Tournament) Config() GameConfig {
+	return t.config
+}
+
+func (t *Tournament) Paused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return !t.stopped && t.runningSince.IsZero()
+}
+
+// Level returns the blind level in play. Breaks count as levels, so use Blind
+// for the last blind amount reached.
+func (t *Tournament) Level() BlindLevel {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.levelAt(t.gameTime())
+}
+
+func (t *Tournament) Blind() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.blindLevel().Blind
+}
+
+// blindLevel returns the last level with blinds that has been reached, which
+// is the one still played through a break.
+func (t *Tournament) blindLevel() BlindLevel {
+	gameTime := t.gameTime()
+	var level BlindLevel
+	for _, l := range t.levels {
+		if l.At > gameTime {
+			break
+		}
+		if !l.Level.Break {
+			level = l.Level
+		}
+	}
+	return level
+}
+
+// Remaining returns how long the current level has left, or zero on the last
+// level.
+func (t *Tournament) Remaining() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gameTime := t.gameTime()
+	for _, l := range t.levels {
+		if l.At > gameTime {
+			return l.At - gameTime
+		}
+	}
+	return 0
+}
+
+func (t *Tournament) levelAt(gameTime time.Duration) BlindLevel {
+	var level BlindLevel
+	for _, l := range t.levels {
+		if l.At > gameTime {
+			break
+		}
+		level = l.Level
+	}
+	return level
+}
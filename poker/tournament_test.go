@@ -0,0 +1,228 @@
+package poker
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
+)
+
+func TestTournament(t *testing.T) {
+	standard, _ := newBlindStructures(PresetBlindStructures()).get(DefaultBlindStructure)
+
+	newStartedTournament := func() (*Tournament, *SpyBlindAlerter, *tutils.FakeClock) {
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		alerter := &SpyBlindAlerter{}
+		tournament := newTournament(alerter, clock, GameConfig{NumOfPlayers: 5}, standard, io.Discard)
+		tournament.start()
+		return tournament, alerter, clock
+	}
+
+	t.Run("stop cancels every pending alert", func(t *testing.T) {
+		tournament, alerter, _ := newStartedTournament()
+
+		tournament.Stop()
+
+		AssertAlertsStopped(t, alerter)
+	})
+
+	t.Run("pause freezes the clock and resume picks up where it left", func(t *testing.T) {
+		tournament, alerter, clock := newStartedTournament()
+
+		clock.Advance(15 * time.Minute)
+		tournament.Pause()
+		AssertAlertsStopped(t, alerter)
+
+		clock.Advance(time.Hour)
+		if tournament.Blind() != 200 {
+			t.Errorf("got blind %d while paused, want %d", tournament.Blind(), 200)
+		}
+
+		alerter.alerts = nil
+		tournament.Resume()
+
+		cases := []ScheduledAlert{
+			{5 * time.Minute, 300},
+			{15 * time.Minute, 400},
+			{25 * time.Minute, 500},
+		}
+		checkSchedulingCases(t, cases, alerter)
+	})
+
+	t.Run("next level announces the next blind straight away", func(t *testing.T) {
+		tournament, alerter, clock := newStartedTournament()
+
+		clock.Advance(15 * time.Minute)
+		alerter.alerts = nil
+		tournament.NextLevel()
+
+		cases := []ScheduledAlert{
+			{0 * time.Second, 300},
+			{10 * time.Minute, 400},
+		}
+		checkSchedulingCases(t, cases, alerter)
+		if tournament.Blind() != 300 {
+			t.Errorf("got blind %d, want %d", tournament.Blind(), 300)
+		}
+	})
+
+	t.Run("next level while paused stays paused", func(t *testing.T) {
+		tournament, alerter, _ := newStartedTournament()
+
+		tournament.Pause()
+		alerter.alerts = nil
+		tournament.NextLevel()
+
+		checkSchedulingCases(t, []ScheduledAlert{{0 * time.Second, 200}}, alerter)
+		if len(alerter.alerts) != 1 {
+			t.Errorf("got %d alerts scheduled, want 1", len(alerter.alerts))
+		}
+		if !tournament.Paused() {
+			t.Error("tournament should still be paused")
+		}
+	})
+
+	t.Run("remaining time in the level", func(t *testing.T) {
+		tournament, _, clock := newStartedTournament()
+
+		clock.Advance(13 * time.Minute)
+		tournament.Pause()
+		clock.Advance(time.Hour)
+
+		if tournament.Remaining() != 7*time.Minute {
+			t.Errorf("got %v remaining, want %v", tournament.Remaining(), 7*time.Minute)
+		}
+	})
+
+	t.Run("a stopped tournament can't be resumed", func(t *testing.T) {
+		tournament, alerter, _ := newStartedTournament()
+
+		tournament.Stop()
+		alerter.alerts = nil
+		tournament.Resume()
+		tournament.NextLevel()
+
+		if len(alerter.alerts) != 0 {
+			t.Errorf("got %d alerts after stopping, want none", len(alerter.alerts))
+		}
+	})
+}
+
+func TestTournamentOnFakeClock(t *testing.T) {
+	newStartedGame := func() (*Tournament, *tutils.FakeClock, *bytes.Buffer) {
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		game := NewTexasHoldem(NewAlerter(clock), tutils.NewStubStorage()).WithClock(clock)
+		out := &bytes.Buffer{}
+		tournament, err := game.Start(GameConfig{NumOfPlayers: 5}, out)
+		tutils.AssertNoError(t, err)
+		return tournament, clock, out
+	}
+
+	t.Run("alerts fire as the clock moves", func(t *testing.T) {
+		_, clock, out := newStartedGame()
+
+		assertAlerts(t, out, "")
+
+		clock.Advance(25 * time.Minute)
+		assertAlerts(t, out, "Blind is now 100\nBlind is now 200\nBlind is now 300\n")
+	})
+
+	t.Run("nothing fires while paused", func(t *testing.T) {
+		tournament, clock, out := newStartedGame()
+
+		clock.Advance(5 * time.Minute)
+		tournament.Pause()
+		clock.Advance(time.Hour)
+		assertAlerts(t, out, "Blind is now 100\n")
+
+		tournament.Resume()
+		clock.Advance(5 * time.Minute)
+		assertAlerts(t, out, "Blind is now 100\nBlind is now 200\n")
+		if clock.Now().Sub(tournament.StartedAt()) != 70*time.Minute {
+			t.Errorf("got %v since the start, want %v", clock.Now().Sub(tournament.StartedAt()), 70*time.Minute)
+		}
+	})
+
+	t.Run("finish records times from the clock", func(t *testing.T) {
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		storage := tutils.NewStubStorage()
+		game := NewTexasHoldem(NewAlerter(clock), storage).WithClock(clock)
+		tournament, _ := game.Start(GameConfig{NumOfPlayers: 5}, io.Discard)
+
+		clock.Advance(90 * time.Minute)
+		game.Finish(tournament, "Ruth")
+
+		if storage.Games[0].Duration() != 90*time.Minute {
+			t.Errorf("got duration %v, want %v", storage.Games[0].Duration(), 90*time.Minute)
+		}
+		if clock.Pending() != 0 {
+			t.Errorf("got %d alerts still pending after finish, want none", clock.Pending())
+		}
+	})
+}
+
+type levelRecorder struct {
+	levels []BlindLevel
+}
+
+func (l *levelRecorder) Write(p []byte) (int, error) {
+	return 0, errors.New("expected levels, not text")
+}
+
+func (l *levelRecorder) WriteLevel(level BlindLevel) error {
+	l.levels = append(l.levels, level)
+	return nil
+}
+
+func TestAlerterLevelWriter(t *testing.T) {
+	clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+	recorder := &levelRecorder{}
+
+	NewAlerter(clock).ScheduleAlertAt(time.Minute, BlindLevel{Blind: 200, Ante: 25}, recorder)
+	clock.Advance(time.Minute)
+
+	if len(recorder.levels) != 1 || recorder.levels[0] != (BlindLevel{Blind: 200, Ante: 25}) {
+		t.Errorf("got levels %v, want the one scheduled", recorder.levels)
+	}
+}
+
+func assertAlerts(t testing.TB, out *bytes.Buffer, want string) {
+	t.Helper()
+	if out.String() != want {
+		t.Errorf("got alerts %q, want %q", out.String(), want)
+	}
+}
+
+func TestCLIClockCommands(t *testing.T) {
+	t.Run("next level is recorded as the last blind", func(t *testing.T) {
+		storage := tutils.NewStubStorage()
+		alerter := &SpyBlindAlerter{}
+		game := NewTexasHoldem(alerter, storage)
+
+		cli := NewCLI(userInput("5", NextLevelCommand, NextLevelCommand, "Chris wins"), &bytes.Buffer{}, game)
+		cli.PlayPoker()
+
+		tutils.AssertPlayerWin(t, storage, "Chris")
+		if storage.Games[0].LastBlind != 300 {
+			t.Errorf("got last blind %d, want %d", storage.Games[0].LastBlind, 300)
+		}
+		AssertAlertsStopped(t, alerter)
+	})
+
+	t.Run("running out of input stops the clock without a winner", func(t *testing.T) {
+		storage := tutils.NewStubStorage()
+		alerter := &SpyBlindAlerter{}
+		game := NewTexasHoldem(alerter, storage)
+
+		cli := NewCLI(userInput("5", PauseCommand, ResumeCommand), &bytes.Buffer{}, game)
+		cli.PlayPoker()
+
+		if len(storage.WinCalls) != 0 {
+			t.Errorf("expected no winner, got %v", storage.WinCalls)
+		}
+		AssertAlertsStopped(t, alerter)
+	})
+}
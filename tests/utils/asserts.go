@@ -18,7 +18,7 @@ func AssertContentType(t testing.TB, response httptest.ResponseRecorder, want st
 func AssertLeague(t testing.TB, got, want leaguedb.League) {
 	t.Helper()
 	if !slices.Equal(got, want) {
-		t.Errorf("players table is wrong, got %q, want %q", got, want)
+		t.Errorf("players table is wrong, got %v, want %v", got, want)
 	}
 }
 
@@ -61,3 +61,16 @@ func AssertPlayerWin(t testing.TB, store *StubStorage, winner string) {
 		t.Errorf("did not store correct winner got %q want %q", store.WinCalls[0], winner)
 	}
 }
+
+func AssertGames(t testing.TB, got, want []leaguedb.GameRecord) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d games, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].StartedAt.Equal(want[i].StartedAt) || !got[i].FinishedAt.Equal(want[i].FinishedAt) ||
+			got[i].Players != want[i].Players || got[i].LastBlind != want[i].LastBlind || got[i].Winner != want[i].Winner {
+			t.Errorf("game %d is wrong, got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
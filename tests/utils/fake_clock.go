@@ -0,0 +1,127 @@
+package tutils
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/clock"
+)
+
+// FakeClock only moves when Advance is called. Funcs scheduled with AfterFunc
+// run synchronously inside Advance, in the order they are due.
+type FakeClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	f     func()
+	done  bool
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	c := &FakeClock{now: start}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) clock.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	c.changed.Broadcast()
+	return timer
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due on
+// the way. Timers scheduled by a firing func also run if they are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for {
+		timer := c.nextDue(end)
+		if timer == nil {
+			break
+		}
+		timer.done = true
+		c.now = timer.at
+		c.mu.Unlock()
+		timer.f()
+		c.mu.Lock()
+	}
+	c.now = end
+	c.changed.Broadcast()
+	c.mu.Unlock()
+}
+
+// Pending returns how many timers are waiting to fire.
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending()
+}
+
+// BlockUntil waits until at least n timers are pending, for tests where the
+// timers are scheduled by another goroutine.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.pending() < n {
+		c.changed.Wait()
+	}
+}
+
+func (c *FakeClock) pending() int {
+	count := 0
+	for _, timer := range c.timers {
+		if !timer.done {
+			count++
+		}
+	}
+	return count
+}
+
+func (c *FakeClock) nextDue(end time.Time) *fakeTimer {
+	c.timers = c.timers[:removeDone(c.timers)]
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+	if len(c.timers) == 0 || c.timers[0].at.After(end) {
+		return nil
+	}
+	return c.timers[0]
+}
+
+func removeDone(timers []*fakeTimer) int {
+	kept := 0
+	for _, timer := range timers {
+		if !timer.done {
+			timers[kept] = timer
+			kept++
+		}
+	}
+	return kept
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasPending := !t.done
+	t.done = true
+	t.clock.changed.Broadcast()
+	return wasPending
+}
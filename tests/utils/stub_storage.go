@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 )
@@ -13,6 +15,13 @@ type StubStorage struct {
 	mu       sync.Mutex
 	Scores   map[string]int
 	WinCalls []string
+	Games    []leaguedb.GameRecord
+	Ratings  map[string]float64
+	Winnings map[string]int
+	Sessions []leaguedb.Session
+
+	// RecordGameErr is returned by RecordGame instead of recording the game.
+	RecordGameErr error
 }
 
 func NewStubStorage() *StubStorage {
@@ -38,14 +47,85 @@ func (s *StubStorage) PostPlayerScore(name string) error {
 func (s *StubStorage) RecordWin(name string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.recordWin(name)
+}
+
+func (s *StubStorage) recordWin(name string) {
 	s.Scores[name]++
 	s.WinCalls = append(s.WinCalls, name)
 }
 
+func (s *StubStorage) DeletePlayer(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Scores[name]; !ok {
+		return fmt.Errorf("can't delete %q, %w", name, leaguedb.ErrPlayerNotFound)
+	}
+	delete(s.Scores, name)
+	return nil
+}
+
+func (s *StubStorage) RenamePlayer(from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wins, ok := s.Scores[from]
+	if !ok {
+		return fmt.Errorf("can't rename %q, %w", from, leaguedb.ErrPlayerNotFound)
+	}
+	if _, taken := s.Scores[to]; taken && from != to {
+		return fmt.Errorf("can't rename %q to %q, %w", from, to, leaguedb.ErrPlayerExists)
+	}
+	delete(s.Scores, from)
+	s.Scores[to] = wins
+	return nil
+}
+
+func (s *StubStorage) RecordGame(game leaguedb.GameRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.RecordGameErr != nil {
+		return s.RecordGameErr
+	}
+	s.recordWin(game.Winner)
+	s.Games = append(s.Games, game)
+	return nil
+}
+
+func (s *StubStorage) GetGames() ([]leaguedb.GameRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Games, nil
+}
+
+func (s *StubStorage) StartSession(at time.Time) (leaguedb.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := leaguedb.Session{ID: strconv.Itoa(len(s.Sessions) + 1), StartedAt: at}
+	s.Sessions = append(s.Sessions, session)
+	return session, nil
+}
+
+func (s *StubStorage) AddLedgerEntry(sessionID string, entry leaguedb.LedgerEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc := leaguedb.Document{Sessions: s.Sessions}
+	if err := doc.AddLedgerEntry(sessionID, entry); err != nil {
+		return err
+	}
+	s.Sessions = doc.Sessions
+	return nil
+}
+
+func (s *StubStorage) GetSessions() ([]leaguedb.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Sessions, nil
+}
+
 func (s *StubStorage) GetLeagueTable() (leaguedb.League, error) {
 	leag := make(leaguedb.League, 0, len(s.Scores))
 	for name, wins := range s.Scores {
-		leag = append(leag, leaguedb.Player{Name: name, Wins: wins})
+		leag = append(leag, leaguedb.Player{Name: name, Wins: wins, Rating: s.Ratings[name], Winnings: s.Winnings[name]})
 	}
 	sort.Slice(leag, func(i, j int) bool {
 		return leag[i].Wins > leag[j].Wins
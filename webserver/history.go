@@ -0,0 +1,68 @@
+package webserver
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/shortykevich/go-with-tests-app/poker"
+)
+
+// handHistoryHandler answers the hands a running or recorded game has
+// finished on GET, as JSON or as text with ?format=text. Only the cards that
+// were shown are in them.
+func (p *PlayersScoreServer) handHistoryHandler(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	history, found, err := p.handHistory(id)
+	switch {
+	case err != nil:
+		log.Printf("Couldn't get hand history. Error occurred. %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	case !found:
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		writeJSON(w, http.StatusOK, history)
+	case "text":
+		w.Header().Set("content-type", "text/plain; charset=utf-8")
+		if err := poker.WriteHandHistories(w, history); err != nil {
+			log.Printf("Unable to write hand history. Error occurred. %v", err)
+		}
+	default:
+		http.Error(w, "format is either \"json\" or \"text\"", http.StatusBadRequest)
+	}
+}
+
+// handHistory returns the hands of the running game with the ID, or of the
+// recorded one once it is over.
+func (p *PlayersScoreServer) handHistory(id string) ([]poker.HandHistory, bool, error) {
+	if running, err := p.games.Get(id); err == nil {
+		return running.Tournament.HandHistory(), true, nil
+	}
+
+	games, err := p.storage.GetGames()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, game := range games {
+		if game.ID != id {
+			continue
+		}
+		history := []poker.HandHistory{}
+		if len(game.Hands) > 0 {
+			if err := json.Unmarshal(game.Hands, &history); err != nil {
+				return nil, false, err
+			}
+		}
+		return history, true, nil
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,105 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+)
+
+// sessionReport is a ledger session with who owes whom so far.
+type sessionReport struct {
+	leaguedb.Session
+	leaguedb.Settlement
+}
+
+func newSessionReport(session leaguedb.Session) sessionReport {
+	return sessionReport{Session: session, Settlement: session.Settle()}
+}
+
+// ledger returns the storage's ledger, or answers 501 when it doesn't keep one.
+func (p *PlayersScoreServer) ledger(w http.ResponseWriter) (leaguedb.LedgerStorage, bool) {
+	ledger, ok := p.storage.(leaguedb.LedgerStorage)
+	if !ok {
+		http.Error(w, "this storage doesn't keep a ledger", http.StatusNotImplemented)
+	}
+	return ledger, ok
+}
+
+// ledgerHandler lists the sessions on GET and starts a new one on POST.
+func (p *PlayersScoreServer) ledgerHandler(w http.ResponseWriter, r *http.Request) {
+	ledger, ok := p.ledger(w)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		sessions, err := ledger.GetSessions()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, sessions)
+	case http.MethodPost:
+		session, err := ledger.StartSession(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newSessionReport(session))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// sessionHandler reports a session and its settlement on GET. POST adds a
+// buy-in or cash-out to it.
+func (p *PlayersScoreServer) sessionHandler(w http.ResponseWriter, r *http.Request) {
+	ledger, ok := p.ledger(w)
+	if !ok {
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/ledger/")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var entry leaguedb.LedgerEntry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			http.Error(w, "expected a JSON body with the Player, Kind and Amount", http.StatusBadRequest)
+			return
+		}
+		err := ledger.AddLedgerEntry(id, entry)
+		switch {
+		case errors.Is(err, leaguedb.ErrSessionNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, leaguedb.ErrBadLedgerEntry):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessions, err := ledger.GetSessions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, session := range sessions {
+		if session.ID == id {
+			writeJSON(w, http.StatusOK, newSessionReport(session))
+			return
+		}
+	}
+	http.Error(w, leaguedb.ErrSessionNotFound.Error(), http.StatusNotFound)
+}
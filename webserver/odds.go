@@ -0,0 +1,55 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/shortykevich/go-with-tests-app/poker"
+)
+
+// maxOddsHands is the most hands POST /odds works out, a full table.
+const maxOddsHands = 10
+
+// oddsBody is the cards to work out the odds for, e.g.
+// {"Hands": [["Ah", "Kd"], ["Qs", "Qc"]], "Board": ["2c", "7d", "9h"]}. Every
+// board is dealt unless Samples asks for that many random ones.
+type oddsBody struct {
+	Hands   [][]poker.Card
+	Board   []poker.Card
+	Samples int
+	Seed    uint64
+}
+
+// oddsHandler answers how often each hand wins on POST.
+func (p *PlayersScoreServer) oddsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body oddsBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "expected a JSON body with the Hands and an optional Board, Samples and Seed", http.StatusBadRequest)
+		return
+	}
+	if len(body.Hands) > maxOddsHands {
+		http.Error(w, fmt.Sprintf("%d hands is more than a table of %d", len(body.Hands), maxOddsHands), http.StatusBadRequest)
+		return
+	}
+	odds, err := poker.CalculateOdds(poker.OddsConfig{
+		Hands:   body.Hands,
+		Board:   body.Board,
+		Samples: body.Samples,
+		Seed:    body.Seed,
+	})
+	switch {
+	case errors.Is(err, poker.ErrBadHand), errors.Is(err, poker.ErrBadOdds), errors.Is(err, poker.ErrNotEnoughPlayers):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, odds)
+	}
+}
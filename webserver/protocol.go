@@ -0,0 +1,220 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
+	"github.com/shortykevich/go-with-tests-app/poker"
+)
+
+// ProtocolVersion is the version of the websocket messages. Clients send it with
+// every message and messages for any other version are refused.
+const ProtocolVersion = 1
+
+// Messages sent by the client.
+const (
+	StartGameMsg     = "start_game"
+	ResumeGameMsg    = "resume_game"
+	PauseMsg         = "pause"
+	ResumeMsg        = "resume"
+	NextLevelMsg     = "next_level"
+	DeclareWinnerMsg = "declare_winner"
+	EliminateMsg     = "eliminate"
+	RebuyMsg         = "rebuy"
+	AddOnMsg         = "add_on"
+	DealHandMsg      = "deal_hand"
+	ActMsg           = "act"
+	HoleCardsMsg     = "hole_cards"
+)
+
+// Messages sent by the server.
+const (
+	GameStartedMsg  = "game_started"
+	GameResumedMsg  = "game_resumed"
+	BlindChangedMsg = "blind_changed"
+	GameOverMsg     = "game_over"
+	PlayerEventMsg  = "player_event"
+	PayoutsMsg      = "payouts"
+	HandMsg         = "hand"
+	NoticeMsg       = "notice"
+	ErrorMsg        = "error"
+)
+
+// Error codes sent in error messages.
+const (
+	ErrCodeBadMessage         = "bad_message"
+	ErrCodeUnsupportedVersion = "unsupported_version"
+	ErrCodeUnknownType        = "unknown_type"
+	ErrCodeInvalidMessage     = "invalid_message"
+	ErrCodeNoGame             = "no_game"
+	ErrCodeGameRunning        = "game_running"
+	ErrCodeUnknownSession     = "unknown_session"
+	ErrCodeUnknownSeat        = "unknown_seat"
+	ErrCodeReadOnly           = "read_only"
+	ErrCodeBadResult          = "bad_result"
+	ErrCodeBadEvent           = "bad_event"
+	ErrCodeBadAction          = "bad_action"
+	ErrCodeStorage            = "storage"
+)
+
+// Message is every message of the websocket protocol. Type decides which of
+// the other fields are used.
+type Message struct {
+	Version          int
+	Type             string
+	Game             string             `json:",omitempty"`
+	Session          string             `json:",omitempty"` // only sent to the host, who resumes with it
+	Seats            map[string]string  `json:",omitempty"` // only sent to the host, who hands each player theirs
+	Seat             string             `json:",omitempty"` // the player's own, to get their hole_cards with
+	Players          int                `json:",omitempty"`
+	Names            []string           `json:",omitempty"`
+	Structure        string             `json:",omitempty"`
+	StartingStack    int                `json:",omitempty"`
+	BuyIn            int                `json:",omitempty"`
+	PrizePool        int                `json:",omitempty"`
+	Payouts          []leaguedb.Payout  `json:",omitempty"`
+	Winner           string             `json:",omitempty"`
+	Placings         []string           `json:",omitempty"` // from the winner down, with or instead of Winner
+	Level            *poker.BlindLevel  `json:",omitempty"`
+	Text             string             `json:",omitempty"`
+	RemainingSeconds int                `json:",omitempty"`
+	Paused           bool               `json:",omitempty"`
+	Player           string             `json:",omitempty"` // who an event, action or hole_cards is for
+	Event            string             `json:",omitempty"`
+	Remaining        int                `json:",omitempty"`
+	AverageStack     int                `json:",omitempty"`
+	Action           string             `json:",omitempty"`
+	Amount           int                `json:",omitempty"` // what a bet or raise is to
+	Actions          []poker.HandAction `json:",omitempty"`
+	Hand             *poker.HandState   `json:",omitempty"`
+	Cards            []poker.Card       `json:",omitempty"` // only sent in reply to hole_cards
+	Error            *ProtocolError     `json:",omitempty"`
+}
+
+type ProtocolError struct {
+	Code    string
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func protocolErrorf(code, format string, a ...any) *ProtocolError {
+	return &ProtocolError{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// parseMessage decodes a client message and checks it has what its type needs.
+func parseMessage(data []byte) (Message, *ProtocolError) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return Message{}, protocolErrorf(ErrCodeBadMessage, "problem parsing message, %v", err)
+	}
+	if msg.Version != ProtocolVersion {
+		return Message{}, protocolErrorf(ErrCodeUnsupportedVersion, "got version %d, want %d", msg.Version, ProtocolVersion)
+	}
+
+	switch msg.Type {
+	case StartGameMsg:
+		if msg.Players <= 0 && len(msg.Names) == 0 {
+			return Message{}, protocolErrorf(ErrCodeInvalidMessage, "%s needs a positive number of Players or their Names", msg.Type)
+		}
+	case ResumeGameMsg:
+		if msg.Session == "" {
+			return Message{}, protocolErrorf(ErrCodeInvalidMessage, "%s needs a Session", msg.Type)
+		}
+	case DeclareWinnerMsg:
+		if msg.Winner == "" && len(msg.Placings) == 0 {
+			return Message{}, protocolErrorf(ErrCodeInvalidMessage, "%s needs a Winner or Placings", msg.Type)
+		}
+		if msg.Winner != "" && len(msg.Placings) > 0 && msg.Placings[0] != msg.Winner {
+			return Message{}, protocolErrorf(ErrCodeInvalidMessage, "%s Winner must come first in Placings", msg.Type)
+		}
+	case EliminateMsg, RebuyMsg, AddOnMsg:
+		if strings.TrimSpace(msg.Player) == "" {
+			return Message{}, protocolErrorf(ErrCodeInvalidMessage, "%s needs a Player", msg.Type)
+		}
+	case HoleCardsMsg:
+		if msg.Seat == "" {
+			return Message{}, protocolErrorf(ErrCodeInvalidMessage, "%s needs a Seat", msg.Type)
+		}
+	case ActMsg:
+		if strings.TrimSpace(msg.Player) == "" || msg.Action == "" {
+			return Message{}, protocolErrorf(ErrCodeInvalidMessage, "%s needs a Player and an Action", msg.Type)
+		}
+	case PauseMsg, ResumeMsg, NextLevelMsg, DealHandMsg:
+	default:
+		return Message{}, protocolErrorf(ErrCodeUnknownType, "unknown message type %q", msg.Type)
+	}
+	return msg, nil
+}
+
+func writeMessage(to io.Writer, msg Message) error {
+	msg.Version = ProtocolVersion
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("problem encoding %s message, %v", msg.Type, err)
+	}
+	_, err = to.Write(data)
+	return err
+}
+
+func writeError(to io.Writer, err *ProtocolError) error {
+	return writeMessage(to, Message{Type: ErrorMsg, Error: err})
+}
+
+// protocolWriter turns what a game writes into protocol messages: blind levels
+// become blind_changed, player events player_event, hands hand, payouts
+// payouts, the result game_over and any other text a notice.
+type protocolWriter struct {
+	to io.Writer
+}
+
+func (w protocolWriter) WriteLevel(level poker.BlindLevel) error {
+	to := w.to
+	if hub, ok := to.(*poker.Hub); ok {
+		to = hub.Levels()
+	}
+	return writeMessage(to, Message{Type: BlindChangedMsg, Level: &level, Text: level.String()})
+}
+
+func (w protocolWriter) WriteResult(placings []string) error {
+	msg := Message{Type: GameOverMsg, Winner: placings[0]}
+	if len(placings) > 1 {
+		msg.Placings = placings
+	}
+	return writeMessage(w.to, msg)
+}
+
+func (w protocolWriter) WriteEvent(event leaguedb.GameEvent, field poker.Field) error {
+	return writeMessage(w.to, Message{
+		Type:         PlayerEventMsg,
+		Event:        event.Kind,
+		Player:       event.Player,
+		Text:         fmt.Sprintf("%s, %s", event, field),
+		Remaining:    field.Remaining,
+		AverageStack: field.AverageStack,
+	})
+}
+
+func (w protocolWriter) WriteHand(actions []poker.HandAction, state poker.HandState) error {
+	lines := make([]string, len(actions))
+	for i, a := range actions {
+		lines[i] = a.String()
+	}
+	return writeMessage(w.to, Message{Type: HandMsg, Actions: actions, Hand: &state, Text: strings.Join(lines, "\n")})
+}
+
+func (w protocolWriter) WritePayouts(pool int, payouts []leaguedb.Payout) error {
+	return writeMessage(w.to, Message{Type: PayoutsMsg, PrizePool: pool, Payouts: payouts})
+}
+
+func (w protocolWriter) Write(p []byte) (int, error) {
+	if err := writeMessage(w.to, Message{Type: NoticeMsg, Text: strings.TrimSpace(string(p))}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -2,12 +2,17 @@ package webserver
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
@@ -17,6 +22,10 @@ import (
 const (
 	jsonContentType  = "application/json"
 	htmlTemplatePath = "game.html"
+
+	// wsWriteWait is how long a write to a websocket can take before the
+	// connection is given up on.
+	wsWriteWait = 10 * time.Second
 )
 
 var wsUpgrader = websocket.Upgrader{
@@ -29,10 +38,40 @@ type PlayersScoreServer struct {
 	http.Handler
 	template *template.Template
 	game     poker.Game
+	games    *poker.GameRegistry
+	points   leaguedb.PointsTable
+}
+
+// createGameBody is the body of POST /games. Either the number of Players or
+// their Names is needed.
+type createGameBody struct {
+	Players       int
+	Names         []string
+	Structure     string
+	StartingStack int
+	BuyIn         int
+}
+
+// gameCommandBody is the body of POST /games/{id}. A Winner or Placings
+// finishes the game.
+type gameCommandBody struct {
+	Command  string // pause, resume, next, out, rebuy, addon or deal
+	Player   string // who out, rebuy, addon or the Action is for
+	Action   string
+	Amount   int // what a bet or raise is to
+	Winner   string
+	Placings []string
+}
+
+type gamePage struct {
+	BlindStructures []string
+	Default         string
+	Running         []poker.GameStatus
 }
 
 type playerServerWS struct {
 	*websocket.Conn
+	mu sync.Mutex
 }
 
 func NewPlayersScoreServer(storage leaguedb.PlayersStorage, game poker.Game) (*PlayersScoreServer, error) {
@@ -46,18 +85,39 @@ func NewPlayersScoreServer(storage leaguedb.PlayersStorage, game poker.Game) (*P
 	serv.template = tmpl
 	serv.storage = storage
 	serv.game = game
+	serv.points = leaguedb.DefaultPointsTable
+	games, err := storage.GetGames()
+	if err != nil {
+		return nil, fmt.Errorf("problem loading games %v", err)
+	}
+	serv.games = poker.NewGameRegistry(game).ContinueFrom(games).WithOutput(func(w io.Writer) io.Writer {
+		return protocolWriter{w}
+	})
 
 	router := http.NewServeMux()
 	router.Handle("/ws", http.HandlerFunc(serv.webSocket))
 	router.Handle("/game", http.HandlerFunc(serv.newGameHandler))
 	router.Handle("/league", http.HandlerFunc(serv.leagueHandler))
+	router.Handle("/standings", http.HandlerFunc(serv.standingsHandler))
+	router.Handle("/games", http.HandlerFunc(serv.gamesHandler))
+	router.Handle("/games/", http.HandlerFunc(serv.gameHandler))
 	router.Handle("/players/", http.HandlerFunc(serv.playersHandler))
+	router.Handle("/ledger", http.HandlerFunc(serv.ledgerHandler))
+	router.Handle("/ledger/", http.HandlerFunc(serv.sessionHandler))
+	router.Handle("/odds", http.HandlerFunc(serv.oddsHandler))
 
 	serv.Handler = router
 
 	return serv, nil
 }
 
+// WithPointsTable changes the points table /standings scores places with when
+// the request doesn't bring its own.
+func (p *PlayersScoreServer) WithPointsTable(table leaguedb.PointsTable) *PlayersScoreServer {
+	p.points = table
+	return p
+}
+
 func (p *PlayersScoreServer) postWin(w http.ResponseWriter, name string) {
 	if err := p.storage.PostPlayerScore(name); err != nil {
 		w.Write([]byte(err.Error()))
@@ -74,6 +134,40 @@ func (p *PlayersScoreServer) getScore(w http.ResponseWriter, name string) {
 	w.Write([]byte(strconv.Itoa(v)))
 }
 
+func (p *PlayersScoreServer) deletePlayer(w http.ResponseWriter, name string) {
+	if err := p.storage.DeletePlayer(name); err != nil {
+		writeStorageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *PlayersScoreServer) renamePlayer(w http.ResponseWriter, r *http.Request, name string) {
+	var body struct{ Name string }
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		http.Error(w, "expected a JSON body with the new Name", http.StatusBadRequest)
+		return
+	}
+
+	if err := p.storage.RenamePlayer(name, body.Name); err != nil {
+		writeStorageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeStorageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, leaguedb.ErrPlayerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, leaguedb.ErrPlayerExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("storage error %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (p *PlayersScoreServer) leagueHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("content-type", jsonContentType)
 	players, err := p.storage.GetLeagueTable()
@@ -83,6 +177,10 @@ func (p *PlayersScoreServer) leagueHandler(w http.ResponseWriter, r *http.Reques
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	if err := players.OrderBy(r.URL.Query().Get("sort")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	err = json.NewEncoder(w).Encode(players)
 	if err != nil {
 		log.Printf("Unable to parse Players table. Error occurred. %v", err)
@@ -91,6 +189,214 @@ func (p *PlayersScoreServer) leagueHandler(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// standingsHandler scores the finished games with the server's points table,
+// or the one in ?points=, e.g. ?points=10,6,4.
+func (p *PlayersScoreServer) standingsHandler(w http.ResponseWriter, r *http.Request) {
+	table := p.points
+	if points := r.URL.Query().Get("points"); points != "" {
+		var err error
+		if table, err = leaguedb.ParsePointsTable(points); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	games, err := p.storage.GetGames()
+	if err != nil {
+		log.Printf("Couldn't get games history. Error occurred. %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, leaguedb.Standings(games, table))
+}
+
+func (p *PlayersScoreServer) gamesHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost:
+		p.createGame(w, r)
+	case r.Method != http.MethodGet:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	case r.URL.Query().Get("status") == "running":
+		p.runningGames(w)
+	default:
+		p.gamesHistory(w)
+	}
+}
+
+func (p *PlayersScoreServer) gamesHistory(w http.ResponseWriter) {
+	w.Header().Set("content-type", jsonContentType)
+	games, err := p.storage.GetGames()
+
+	if err != nil {
+		log.Printf("Couldn't get games history. Error occurred. %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	// The hands are only sent for one game at a time, from /games/{id}/hands.
+	games = slices.Clone(games)
+	for i := range games {
+		games[i].Hands = nil
+	}
+	err = json.NewEncoder(w).Encode(games)
+	if err != nil {
+		log.Printf("Unable to encode games history. Error occurred. %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (p *PlayersScoreServer) runningGames(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, p.runningStatuses())
+}
+
+func (p *PlayersScoreServer) runningStatuses() []poker.GameStatus {
+	running := p.games.List()
+	statuses := make([]poker.GameStatus, len(running))
+	for i, game := range running {
+		statuses[i] = game.Status()
+	}
+	return statuses
+}
+
+// createGame starts a game that isn't tied to a websocket. It can be watched on
+// /ws?game={id} and controlled through /games/{id}.
+func (p *PlayersScoreServer) createGame(w http.ResponseWriter, r *http.Request) {
+	var body createGameBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || (body.Players <= 0 && len(body.Names) == 0) {
+		http.Error(w, "expected a JSON body with the number of Players or their Names", http.StatusBadRequest)
+		return
+	}
+
+	running, err := p.games.Start(poker.GameConfig{NumOfPlayers: body.Players, Structure: body.Structure, Names: body.Names, StartingStack: body.StartingStack, BuyIn: body.BuyIn})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, running.Status())
+}
+
+func (p *PlayersScoreServer) gameHandler(w http.ResponseWriter, r *http.Request) {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/games/"), "/")
+	switch rest {
+	case "":
+	case "hands":
+		p.handHistoryHandler(w, r, id)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	running, err := p.games.Get(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, running.Status())
+	case http.MethodPost:
+		p.commandGame(w, r, running)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (p *PlayersScoreServer) commandGame(w http.ResponseWriter, r *http.Request, running *poker.RunningGame) {
+	var body gameCommandBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "expected a JSON body with a Command or a Winner", http.StatusBadRequest)
+		return
+	}
+
+	if body.Winner != "" || len(body.Placings) > 0 {
+		err := p.games.Finish(running.ID, placingsOf(body.Winner, body.Placings)...)
+		switch {
+		case errors.Is(err, poker.ErrBadResult):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, poker.ErrGameNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			log.Printf("Couldn't finish game %s. Error occurred. %v", running.ID, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	if body.Action != "" || body.Command == poker.DealCommand {
+		var err error
+		if body.Action != "" {
+			err = running.Tournament.Act(body.Player, body.Action, body.Amount)
+		} else {
+			err = running.Tournament.DealHand()
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, running.Status())
+		return
+	}
+
+	if kind, ok := poker.PlayerCommands[body.Command]; ok {
+		if err := running.Tournament.Record(kind, body.Player); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, running.Status())
+		return
+	}
+
+	if !runCommand(running.Tournament, body.Command) {
+		http.Error(w, fmt.Sprintf("unknown command %q", body.Command), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, running.Status())
+}
+
+// clockCommands maps the websocket messages that control the clock to the
+// commands runCommand knows.
+var clockCommands = map[string]string{
+	PauseMsg:     poker.PauseCommand,
+	ResumeMsg:    poker.ResumeCommand,
+	NextLevelMsg: poker.NextLevelCommand,
+}
+
+// playerEvents maps the websocket messages for player events to the kind of
+// event they record.
+var playerEvents = map[string]string{
+	EliminateMsg: leaguedb.EliminationEvent,
+	RebuyMsg:     leaguedb.RebuyEvent,
+	AddOnMsg:     leaguedb.AddOnEvent,
+}
+
+// runCommand applies one of the clock commands to the tournament and reports
+// whether the command was known.
+func runCommand(tournament *poker.Tournament, command string) bool {
+	switch command {
+	case poker.PauseCommand:
+		tournament.Pause()
+	case poker.ResumeCommand:
+		tournament.Resume()
+	case poker.NextLevelCommand:
+		tournament.NextLevel()
+	default:
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("content-type", jsonContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Unable to encode response. Error occurred. %v", err)
+	}
+}
+
 func (p *PlayersScoreServer) playersHandler(w http.ResponseWriter, r *http.Request) {
 	player := strings.TrimPrefix(r.URL.Path, "/players/")
 
@@ -99,42 +405,264 @@ func (p *PlayersScoreServer) playersHandler(w http.ResponseWriter, r *http.Reque
 		p.postWin(w, player)
 	case http.MethodGet:
 		p.getScore(w, player)
+	case http.MethodDelete:
+		p.deletePlayer(w, player)
+	case http.MethodPatch:
+		p.renamePlayer(w, r, player)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
 
 func (p *PlayersScoreServer) newGameHandler(w http.ResponseWriter, r *http.Request) {
-	p.template.Execute(w, nil)
+	structures := []string{poker.DefaultBlindStructure}
+	if game, ok := p.game.(interface{ BlindStructureNames() []string }); ok {
+		structures = game.BlindStructureNames()
+	}
+	p.template.Execute(w, gamePage{
+		BlindStructures: structures,
+		Default:         poker.DefaultBlindStructure,
+		Running:         p.runningStatuses(),
+	})
 }
 
+// webSocket starts a new game for the connection, or with ?game={id} lets it
+// watch a game that is already running.
 func (p *PlayersScoreServer) webSocket(w http.ResponseWriter, r *http.Request) {
+	if id := r.URL.Query().Get("game"); id != "" {
+		p.watchGame(w, r, id)
+		return
+	}
+
 	ws := newPlayerServerWS(w, r)
 
-	numOfPlayersPrompt := ws.WaitForMsg()
-	numOfPlayers, _ := strconv.Atoi(numOfPlayersPrompt)
-	p.game.Start(numOfPlayers, ws)
+	if ws == nil {
+		return
+	}
+	defer ws.Close()
 
-	winner := ws.WaitForMsg()
-	p.game.Finish(string(winner))
+	running := p.startGameWS(ws)
+	if running == nil {
+		return
+	}
+	running.Viewers.Join(ws)
+	// Replies go through the hub so they reach the host in order with the
+	// game's messages.
+	replies := running.Viewers.To(ws)
+
+	for {
+		msg, err := ws.WaitForProtocolMsg()
+		if err != nil {
+			p.games.Detach(running.ID)
+			return
+		}
+
+		switch msg.Type {
+		case StartGameMsg, ResumeGameMsg:
+			writeError(replies, protocolErrorf(ErrCodeGameRunning, "game %s is already running", running.ID))
+		case DeclareWinnerMsg:
+			if err := p.games.Finish(running.ID, placingsOf(msg.Winner, msg.Placings)...); err != nil {
+				code := ErrCodeBadResult
+				if !errors.Is(err, poker.ErrBadResult) {
+					code = ErrCodeStorage
+				}
+				writeError(replies, protocolErrorf(code, "%v", err))
+				continue
+			}
+			return
+		case EliminateMsg, RebuyMsg, AddOnMsg:
+			if err := running.Tournament.Record(playerEvents[msg.Type], msg.Player); err != nil {
+				writeError(replies, protocolErrorf(ErrCodeBadEvent, "%v", err))
+			}
+		case DealHandMsg:
+			if err := running.Tournament.DealHand(); err != nil {
+				writeError(replies, protocolErrorf(ErrCodeBadAction, "%v", err))
+			}
+		case ActMsg:
+			if err := running.Tournament.Act(msg.Player, msg.Action, msg.Amount); err != nil {
+				writeError(replies, protocolErrorf(ErrCodeBadAction, "%v", err))
+			}
+		case HoleCardsMsg:
+			writeError(replies, protocolErrorf(ErrCodeInvalidMessage, "hole cards are only sent to players following the game with their seat"))
+		default:
+			runCommand(running.Tournament, clockCommands[msg.Type])
+		}
+	}
+}
+
+// startGameWS waits for a valid start_game or resume_game message and sends
+// the host the game's state. It returns nil if the connection goes away first.
+func (p *PlayersScoreServer) startGameWS(ws *playerServerWS) *poker.RunningGame {
+	for {
+		msg, err := ws.WaitForProtocolMsg()
+		if err != nil {
+			return nil
+		}
+
+		switch msg.Type {
+		case StartGameMsg:
+			running, err := p.games.Start(poker.GameConfig{NumOfPlayers: msg.Players, Structure: msg.Structure, Names: msg.Names, StartingStack: msg.StartingStack, BuyIn: msg.BuyIn})
+			if err != nil {
+				writeError(ws, protocolErrorf(ErrCodeInvalidMessage, "could not start the game, %v", err))
+				continue
+			}
+			writeMessage(ws, gameStateMessage(GameStartedMsg, running))
+			return running
+		case ResumeGameMsg:
+			running, err := p.games.Resume(msg.Session)
+			if err != nil {
+				writeError(ws, protocolErrorf(ErrCodeUnknownSession, "no game to resume, it may have been abandoned"))
+				continue
+			}
+			writeMessage(ws, gameStateMessage(GameResumedMsg, running))
+			return running
+		default:
+			writeError(ws, protocolErrorf(ErrCodeNoGame, "start a game before sending %s", msg.Type))
+		}
+	}
+}
+
+// gameStateMessage tells the host everything needed to pick up the game,
+// including the session to resume it with and the players' seats. Viewers are
+// sent it without either.
+func gameStateMessage(msgType string, running *poker.RunningGame) Message {
+	status := running.Status()
+	level := running.Tournament.Level()
+	return Message{
+		Type:             msgType,
+		Game:             status.ID,
+		Session:          running.Session,
+		Seats:            running.Seats,
+		Players:          status.Players,
+		Names:            status.Names,
+		Structure:        status.Structure,
+		Level:            &level,
+		Text:             level.String(),
+		RemainingSeconds: int(running.Tournament.Remaining().Seconds()),
+		Paused:           status.Paused,
+		StartingStack:    running.Tournament.Config().StartingStack,
+		BuyIn:            status.BuyIn,
+		PrizePool:        status.PrizePool,
+		Remaining:        status.Remaining,
+		AverageStack:     status.AverageStack,
+		Hand:             status.Hand,
+	}
+}
+
+// placingsOf returns the finishing order, or just the winner when no order was
+// given.
+func placingsOf(winner string, placings []string) []string {
+	if len(placings) > 0 {
+		return placings
+	}
+	return []string{winner}
+}
+
+// watchGame sends the viewer the game's state and follows it until it is over
+// or the viewer goes away. Viewers can't control the game, so anything they
+// send gets an error, except players asking for their hole cards with their
+// seat.
+func (p *PlayersScoreServer) watchGame(w http.ResponseWriter, r *http.Request, id string) {
+	running, err := p.games.Get(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	ws := newPlayerServerWS(w, r)
+	if ws == nil {
+		return
+	}
+	defer ws.Close()
+
+	state := gameStateMessage(GameStartedMsg, running)
+	state.Session, state.Seats = "", nil
+	if err := writeMessage(ws, state); err != nil {
+		return
+	}
+	if !running.Viewers.Join(ws) {
+		return
+	}
+	defer running.Viewers.Leave(ws)
+
+	left := make(chan struct{})
+	go func() {
+		defer close(left)
+		replies := running.Viewers.To(ws)
+		for {
+			msg, err := ws.WaitForProtocolMsg()
+			if err != nil {
+				return
+			}
+			if msg.Type != HoleCardsMsg {
+				writeError(replies, protocolErrorf(ErrCodeReadOnly, "viewers can't control game %s", id))
+				continue
+			}
+			writeHoleCards(replies, running, msg.Seat)
+		}
+	}()
+
+	select {
+	case <-left:
+	case <-running.Viewers.Done():
+	}
+}
+
+// writeHoleCards sends the cards of the player holding the seat, and nobody
+// else's.
+func writeHoleCards(to io.Writer, running *poker.RunningGame, seat string) error {
+	player, cards, err := running.HoleCards(seat)
+	switch {
+	case errors.Is(err, poker.ErrUnknownSeat):
+		return writeError(to, protocolErrorf(ErrCodeUnknownSeat, "no player has that seat in game %s", running.ID))
+	case err != nil:
+		return writeError(to, protocolErrorf(ErrCodeBadAction, "%v", err))
+	}
+	return writeMessage(to, Message{Type: HoleCardsMsg, Player: player, Cards: cards})
 }
 
 func newPlayerServerWS(w http.ResponseWriter, r *http.Request) *playerServerWS {
 	conn, err := wsUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("problem upgrading connection to WebSockets %v\n", err)
+		return nil
 	}
 
 	return &playerServerWS{Conn: conn}
 }
 
-func (w *playerServerWS) WaitForMsg() string {
+func (w *playerServerWS) WaitForMsg() (string, error) {
 	_, msg, err := w.ReadMessage()
 	if err != nil {
 		log.Printf("error reading from websocket %v\n", err)
 	}
-	return string(msg)
+	return string(msg), err
 }
 
+// WaitForProtocolMsg returns the next valid protocol message. Invalid ones are
+// answered with an error message and skipped.
+func (w *playerServerWS) WaitForProtocolMsg() (Message, error) {
+	for {
+		data, err := w.WaitForMsg()
+		if err != nil {
+			return Message{}, err
+		}
+		msg, protoErr := parseMessage([]byte(data))
+		if protoErr != nil {
+			writeError(w, protoErr)
+			continue
+		}
+		return msg, nil
+	}
+}
+
+// Write is called from the blind alert timers as well as the handler, and a
+// websocket connection only supports one writer at a time.
 func (w *playerServerWS) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.SetWriteDeadline(time.Now().Add(wsWriteWait))
 	err = w.WriteMessage(websocket.TextMessage, p)
 
 	if err != nil {
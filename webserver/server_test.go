@@ -2,15 +2,18 @@ package webserver
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/shortykevich/go-with-tests-app/clock"
 	"github.com/shortykevich/go-with-tests-app/db/leaguedb"
 	"github.com/shortykevich/go-with-tests-app/poker"
 	tutils "github.com/shortykevich/go-with-tests-app/tests/utils"
@@ -18,7 +21,6 @@ import (
 
 var (
 	dummyGame = &poker.GameSpy{}
-	tenMS     = 10 * time.Millisecond
 )
 
 func TestPlayersScores(t *testing.T) {
@@ -99,6 +101,472 @@ func TestStoreWins(t *testing.T) {
 	})
 }
 
+func TestDeletePlayer(t *testing.T) {
+	storage := &tutils.StubStorage{
+		Scores: map[string]int{"Pepper": 20},
+	}
+	server, err := NewPlayersScoreServer(storage, dummyGame)
+	tutils.AssertNoError(t, err)
+
+	t.Run("deletes an existing player", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newDeleteRequest("Pepper"))
+
+		tutils.AssertStatus(t, resp, http.StatusNoContent)
+		if _, ok := storage.Scores["Pepper"]; ok {
+			t.Errorf("Pepper should have been deleted, got %v", storage.Scores)
+		}
+	})
+
+	t.Run("returns 404 on missing players", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newDeleteRequest("Apollo"))
+
+		tutils.AssertStatus(t, resp, http.StatusNotFound)
+	})
+}
+
+func TestRenamePlayer(t *testing.T) {
+	storage := &tutils.StubStorage{
+		Scores: map[string]int{"Pepper": 20, "Floyd": 10},
+	}
+	server, err := NewPlayersScoreServer(storage, dummyGame)
+	tutils.AssertNoError(t, err)
+
+	t.Run("renames an existing player", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newRenameRequest("Pepper", `{"Name": "Salt"}`))
+
+		tutils.AssertStatus(t, resp, http.StatusNoContent)
+		if storage.Scores["Salt"] != 20 {
+			t.Errorf("Salt should have Pepper's wins, got %v", storage.Scores)
+		}
+	})
+
+	t.Run("returns 404 on missing players", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newRenameRequest("Apollo", `{"Name": "Zeus"}`))
+
+		tutils.AssertStatus(t, resp, http.StatusNotFound)
+	})
+
+	t.Run("returns 409 when the new name is taken", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newRenameRequest("Salt", `{"Name": "Floyd"}`))
+
+		tutils.AssertStatus(t, resp, http.StatusConflict)
+	})
+
+	t.Run("returns 400 without a new name", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newRenameRequest("Salt", `{}`))
+
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+	})
+}
+
+func TestGames(t *testing.T) {
+	t.Run("get request on /games returns the history", func(t *testing.T) {
+		startedAt := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+		storage := tutils.NewStubStorage()
+		storage.Games = []leaguedb.GameRecord{
+			{StartedAt: startedAt, FinishedAt: startedAt.Add(time.Hour), Players: 5, LastBlind: 600, Winner: "Cleo"},
+		}
+		server, err := NewPlayersScoreServer(storage, dummyGame)
+		tutils.AssertNoError(t, err)
+
+		req, _ := http.NewRequest(http.MethodGet, "/games", nil)
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+
+		var got []leaguedb.GameRecord
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("Unable to parse response from server into games, '%v'", err)
+		}
+
+		tutils.AssertStatus(t, resp, http.StatusOK)
+		tutils.AssertContentType(t, *resp, jsonContentType)
+		tutils.AssertGames(t, got, storage.Games)
+	})
+}
+
+func TestRunningGames(t *testing.T) {
+	storage := tutils.NewStubStorage()
+	game := poker.NewTexasHoldem(&poker.SpyBlindAlerter{}, storage)
+	server := mustMakePlayerServer(t, storage, game)
+
+	t.Run("POST /games starts a game per table", func(t *testing.T) {
+		for _, body := range []string{`{"Players": 5}`, `{"Players": 7, "Structure": "turbo"}`} {
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, newCreateGameRequest(body))
+			tutils.AssertStatus(t, resp, http.StatusCreated)
+		}
+
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/games?status=running", nil))
+
+		var got []poker.GameStatus
+		decodeJSON(t, resp.Body, &got)
+		if len(got) != 2 || got[0].ID != "1" || got[1].Structure != "turbo" {
+			t.Errorf("got running games %+v, want the two tables", got)
+		}
+	})
+
+	t.Run("POST /games with the players' names", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newCreateGameRequest(`{"Names": ["Ruth", "Chris", "Cleo"]}`))
+		tutils.AssertStatus(t, resp, http.StatusCreated)
+
+		var got poker.GameStatus
+		decodeJSON(t, resp.Body, &got)
+		if got.Players != 3 || !reflect.DeepEqual(got.Names, []string{"Ruth", "Chris", "Cleo"}) {
+			t.Errorf("got %+v, want Ruth, Chris and Cleo playing", got)
+		}
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest(got.ID, `{"Winner": "Bob"}`))
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+		server.games.Abandon(got.ID)
+	})
+
+	t.Run("POST /games without players", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newCreateGameRequest(`{}`))
+
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+	})
+
+	t.Run("GET /games/{id} and clock commands", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest("2", `{"Command": "pause"}`))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/games/2", nil))
+
+		var got poker.GameStatus
+		decodeJSON(t, resp.Body, &got)
+		tutils.AssertStatus(t, resp, http.StatusOK)
+		if got.ID != "2" || got.Players != 7 || !got.Paused || got.Blind != 100 {
+			t.Errorf("got %+v, want the paused turbo table", got)
+		}
+	})
+
+	t.Run("declaring a winner finishes the game", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest("1", `{"Winner": "Ruth"}`))
+		tutils.AssertStatus(t, resp, http.StatusNoContent)
+		tutils.AssertPlayerWin(t, storage, "Ruth")
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/games/1", nil))
+		tutils.AssertStatus(t, resp, http.StatusNotFound)
+	})
+
+	t.Run("player commands", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest("2", `{"Command": "out", "Player": "Cleo"}`))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+
+		var got poker.GameStatus
+		decodeJSON(t, resp.Body, &got)
+		if got.Remaining != 6 || got.AverageStack != 7*poker.DefaultStartingStack/6 {
+			t.Errorf("got %+v, want 6 players left", got)
+		}
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest("2", `{"Command": "out", "Player": "Cleo"}`))
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+	})
+
+	t.Run("dealing and acting in a hand", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newCreateGameRequest(`{"Names": ["Ruth", "Chris"]}`))
+		var created poker.GameStatus
+		decodeJSON(t, resp.Body, &created)
+		defer server.games.Abandon(created.ID)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest(created.ID, `{"Command": "deal"}`))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest(created.ID, `{"Player": "Ruth", "Action": "fold"}`))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+
+		var got poker.GameStatus
+		decodeJSON(t, resp.Body, &got)
+		if got.Hand == nil || !got.Hand.Over || got.Hand.Seats[1].Stack != poker.DefaultStartingStack+50 {
+			t.Errorf("got hand %+v, want Chris to take the small blind", got.Hand)
+		}
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest(created.ID, `{"Player": "Ruth", "Action": "check"}`))
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+	})
+
+	t.Run("GET /games/{id}/hands exports the hands played", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newCreateGameRequest(`{"Names": ["Ruth", "Chris"]}`))
+		var created poker.GameStatus
+		decodeJSON(t, resp.Body, &created)
+		running, _ := server.games.Get(created.ID)
+		running.Tournament.DealHand()
+		running.Tournament.Act("Ruth", poker.FoldAction, 0)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newHandHistoryRequest(created.ID, ""))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+		var got []poker.HandHistory
+		decodeJSON(t, resp.Body, &got)
+		if len(got) != 1 || got[0].Seats[0].Cards != nil || got[0].Seats[1].Cards != nil {
+			t.Errorf("got %+v, want hand 1 without the cards nobody showed", got)
+		}
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newHandHistoryRequest(created.ID, "?format=xml"))
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest(created.ID, `{"Winner": "Chris"}`))
+		tutils.AssertStatus(t, resp, http.StatusNoContent)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newHandHistoryRequest(created.ID, "?format=text"))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+		want := fmt.Sprintf("PokerStars Hand #%s00001: ", created.ID)
+		if text := resp.Body.String(); !strings.HasPrefix(text, want) || !strings.Contains(text, "Chris collected 100 from pot\n") {
+			t.Errorf("got %q, want hand 1 of the finished game as text", text)
+		}
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/games", nil))
+		var games []leaguedb.GameRecord
+		decodeJSON(t, resp.Body, &games)
+		if last := games[len(games)-1]; last.ID != created.ID || last.Hands != nil {
+			t.Errorf("got %+v, want game %s listed without its hands", last, created.ID)
+		}
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newHandHistoryRequest("99", ""))
+		tutils.AssertStatus(t, resp, http.StatusNotFound)
+	})
+
+	t.Run("unknown commands", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest("2", `{"Command": "shuffle"}`))
+
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+	})
+
+	t.Run("a bad finishing order leaves the game running", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest("2", `{"Placings": ["Cleo", "Cleo"]}`))
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/games/2", nil))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+	})
+
+	t.Run("a result storage fails to record is a server error", func(t *testing.T) {
+		storage.RecordGameErr = errors.New("disk full")
+		defer func() { storage.RecordGameErr = nil }()
+
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest("2", `{"Winner": "Cleo"}`))
+		tutils.AssertStatus(t, resp, http.StatusInternalServerError)
+	})
+
+	t.Run("finishing with the full order", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameCommandRequest("2", `{"Placings": ["Cleo", "Chris", "Ruth"]}`))
+		tutils.AssertStatus(t, resp, http.StatusNoContent)
+
+		got := storage.Games[len(storage.Games)-1].Placings()
+		if !reflect.DeepEqual(got, []string{"Cleo", "Chris", "Ruth"}) {
+			t.Errorf("got placings %v, want Cleo, Chris, Ruth", got)
+		}
+	})
+}
+
+func TestStandings(t *testing.T) {
+	storage := tutils.NewStubStorage()
+	storage.Games = []leaguedb.GameRecord{
+		{Players: 4, Winner: "Cleo", FinishingOrder: []string{"Cleo", "Chris", "Ruth"}},
+		{Players: 4, Winner: "Chris", FinishingOrder: []string{"Chris", "Ruth"}},
+	}
+	server := mustMakePlayerServer(t, storage, dummyGame)
+
+	t.Run("scores places with the server's table", func(t *testing.T) {
+		server.WithPointsTable(leaguedb.PointsTable{10, 6, 4})
+
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/standings", nil))
+
+		var got []leaguedb.Standing
+		decodeJSON(t, resp.Body, &got)
+		tutils.AssertStatus(t, resp, http.StatusOK)
+		tutils.AssertContentType(t, *resp, jsonContentType)
+		want := []leaguedb.Standing{
+			{Name: "Chris", Points: 16, Wins: 1, Games: 2},
+			{Name: "Cleo", Points: 10, Wins: 1, Games: 1},
+			{Name: "Ruth", Points: 10, Wins: 0, Games: 2},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("with a points table in the query", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/standings?points=1", nil))
+
+		var got []leaguedb.Standing
+		decodeJSON(t, resp.Body, &got)
+		if len(got) != 3 || got[0].Name != "Chris" || got[0].Points != 1 {
+			t.Errorf("got %v, want Chris first on 1 point", got)
+		}
+	})
+
+	t.Run("with a bad points table", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/standings?points=lots", nil))
+
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+	})
+}
+
+func TestLedger(t *testing.T) {
+	storage := tutils.NewStubStorage()
+	server := mustMakePlayerServer(t, storage, dummyGame)
+
+	t.Run("POST /ledger starts a session", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/ledger", nil))
+		tutils.AssertStatus(t, resp, http.StatusCreated)
+
+		var got sessionReport
+		decodeJSON(t, resp.Body, &got)
+		if got.ID != "1" {
+			t.Errorf("got session %+v, want session 1", got)
+		}
+	})
+
+	t.Run("buy-ins and cash-outs settle the session", func(t *testing.T) {
+		for _, body := range []string{
+			`{"Player": "Ruth", "Kind": "buyin", "Amount": 100}`,
+			`{"Player": "Chris", "Kind": "buyin", "Amount": 100}`,
+			`{"Player": "Chris", "Kind": "cashout", "Amount": 170}`,
+			`{"Player": "Ruth", "Kind": "cashout", "Amount": 30}`,
+		} {
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, newLedgerEntryRequest("1", body))
+			tutils.AssertStatus(t, resp, http.StatusOK)
+		}
+
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/ledger/1", nil))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+
+		var got sessionReport
+		decodeJSON(t, resp.Body, &got)
+		want := []leaguedb.Transfer{{From: "Ruth", To: "Chris", Amount: 70}}
+		if len(got.Entries) != 4 || !reflect.DeepEqual(got.Transfers, want) {
+			t.Errorf("got %+v, want Ruth paying Chris 70", got)
+		}
+	})
+
+	t.Run("GET /ledger lists the sessions", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/ledger", nil))
+
+		var got []leaguedb.Session
+		decodeJSON(t, resp.Body, &got)
+		if len(got) != 1 {
+			t.Errorf("got %v, want one session", got)
+		}
+	})
+
+	t.Run("bad entries and unknown sessions", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newLedgerEntryRequest("1", `{"Player": "Ruth", "Kind": "buyin", "Amount": -5}`))
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, newLedgerEntryRequest("9", `{"Player": "Ruth", "Kind": "buyin", "Amount": 5}`))
+		tutils.AssertStatus(t, resp, http.StatusNotFound)
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/ledger/9", nil))
+		tutils.AssertStatus(t, resp, http.StatusNotFound)
+	})
+
+	t.Run("storage without a ledger", func(t *testing.T) {
+		server := mustMakePlayerServer(t, struct{ leaguedb.PlayersStorage }{storage}, dummyGame)
+
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/ledger", nil))
+		tutils.AssertStatus(t, resp, http.StatusNotImplemented)
+	})
+}
+
+func TestOdds(t *testing.T) {
+	server := mustMakePlayerServer(t, tutils.NewStubStorage(), dummyGame)
+
+	t.Run("POST /odds deals out the rest of the board", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newOddsRequest(`{"Hands": [["Ah", "Kd"], ["Qs", "Qc"]], "Board": ["2c", "7d", "9h", "3s"]}`))
+		tutils.AssertStatus(t, resp, http.StatusOK)
+
+		var got poker.Odds
+		decodeJSON(t, resp.Body, &got)
+		if got.Boards != 44 || !got.Exhaustive || len(got.Hands) != 2 || got.Hands[0].Cards[0].String() != "Ah" {
+			t.Errorf("got %+v, want every river dealt for both hands", got)
+		}
+	})
+
+	t.Run("samples boards from a seed", func(t *testing.T) {
+		body := `{"Hands": [["Ah", "Kd"], ["Qs", "Qc"]], "Samples": 1000, "Seed": 3}`
+		var first, again poker.Odds
+		for _, got := range []*poker.Odds{&first, &again} {
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, newOddsRequest(body))
+			tutils.AssertStatus(t, resp, http.StatusOK)
+			decodeJSON(t, resp.Body, got)
+		}
+
+		if first.Boards != 1000 || !reflect.DeepEqual(first, again) {
+			t.Errorf("got %+v and %+v, want the same 1000 boards", first, again)
+		}
+	})
+
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"one hand", `{"Hands": [["Ah", "Kd"]]}`},
+		{"a card dealt twice", `{"Hands": [["Ah", "Kd"], ["Ah", "Qc"]]}`},
+		{"a card that doesn't exist", `{"Hands": [["Ah", "Kd"], ["Qs", "Xx"]]}`},
+		{"negative samples", `{"Hands": [["Ah", "Kd"], ["Qs", "Qc"]], "Samples": -1}`},
+		{"more samples than the most", fmt.Sprintf(`{"Hands": [["Ah", "Kd"], ["Qs", "Qc"]], "Samples": %d}`, poker.MaxOddsSamples+1)},
+		{"more hands than a table", `{"Hands": [["2c", "2d"], ["3c", "3d"], ["4c", "4d"], ["5c", "5d"], ["6c", "6d"], ["7c", "7d"], ["8c", "8d"], ["9c", "9d"], ["Tc", "Td"], ["Jc", "Jd"], ["Qc", "Qd"]]}`},
+	}
+	for _, c := range cases {
+		t.Run("refuses "+c.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, newOddsRequest(c.body))
+			tutils.AssertStatus(t, resp, http.StatusBadRequest)
+		})
+	}
+
+	t.Run("only POST", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/odds", nil))
+		tutils.AssertStatus(t, resp, http.StatusMethodNotAllowed)
+	})
+}
+
 func TestLeague(t *testing.T) {
 	t.Run("get request on /league", func(t *testing.T) {
 		storage := &tutils.StubStorage{
@@ -125,6 +593,55 @@ func TestLeague(t *testing.T) {
 		tutils.AssertContentType(t, *resp, jsonContentType)
 	})
 
+	t.Run("get request on /league sorted by rating", func(t *testing.T) {
+		storage := &tutils.StubStorage{
+			Scores:  map[string]int{"Alice": 15, "Bill": 10},
+			Ratings: map[string]float64{"Alice": 1480, "Bill": 1530},
+		}
+		server, err := NewPlayersScoreServer(storage, dummyGame)
+		tutils.AssertNoError(t, err)
+
+		req, _ := http.NewRequest(http.MethodGet, "/league?sort=rating", nil)
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+
+		tutils.AssertStatus(t, resp, http.StatusOK)
+		tutils.AssertLeague(t, getLeagueFromResponse(t, resp.Body), leaguedb.League{
+			{Name: "Bill", Wins: 10, Rating: 1530},
+			{Name: "Alice", Wins: 15, Rating: 1480},
+		})
+	})
+
+	t.Run("get request on /league sorted by winnings", func(t *testing.T) {
+		storage := &tutils.StubStorage{
+			Scores:   map[string]int{"Alice": 15, "Bill": 10},
+			Winnings: map[string]int{"Alice": 40, "Bill": 120},
+		}
+		server, err := NewPlayersScoreServer(storage, dummyGame)
+		tutils.AssertNoError(t, err)
+
+		req, _ := http.NewRequest(http.MethodGet, "/league?sort=winnings", nil)
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+
+		tutils.AssertStatus(t, resp, http.StatusOK)
+		tutils.AssertLeague(t, getLeagueFromResponse(t, resp.Body), leaguedb.League{
+			{Name: "Bill", Wins: 10, Winnings: 120},
+			{Name: "Alice", Wins: 15, Winnings: 40},
+		})
+	})
+
+	t.Run("get request on /league with an unknown sort", func(t *testing.T) {
+		server, err := NewPlayersScoreServer(tutils.NewStubStorage(), dummyGame)
+		tutils.AssertNoError(t, err)
+
+		req, _ := http.NewRequest(http.MethodGet, "/league?sort=luck", nil)
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+
+		tutils.AssertStatus(t, resp, http.StatusBadRequest)
+	})
+
 	t.Run("GET /game return 200", func(t *testing.T) {
 		server, err := NewPlayersScoreServer(tutils.NewStubStorage(), dummyGame)
 		tutils.AssertNoError(t, err)
@@ -137,6 +654,63 @@ func TestLeague(t *testing.T) {
 		tutils.AssertStatus(t, resp, http.StatusOK)
 	})
 
+	t.Run("GET /game lists the blind structures", func(t *testing.T) {
+		game := poker.NewTexasHoldem(&poker.SpyBlindAlerter{}, tutils.NewStubStorage())
+		server, err := NewPlayersScoreServer(tutils.NewStubStorage(), game)
+		tutils.AssertNoError(t, err)
+
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, newGameRequest())
+
+		for _, name := range game.BlindStructureNames() {
+			if !strings.Contains(resp.Body.String(), fmt.Sprintf(`<option value="%s"`, name)) {
+				t.Errorf("expected the page to offer %q", name)
+			}
+		}
+	})
+
+	t.Run("start a game with a blind structure over the websocket", func(t *testing.T) {
+		game := &poker.GameSpy{}
+
+		server := httptest.NewServer(mustMakePlayerServer(t, tutils.NewStubStorage(), game))
+		ws := mustDialWS(t, fmt.Sprintf("ws%s/ws", strings.TrimPrefix(server.URL, "http")))
+
+		defer func() {
+			ws.Close()
+			server.Close()
+		}()
+
+		sendWSMessage(t, ws, Message{Type: StartGameMsg, Players: 4, Structure: "turbo"})
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Winner: "Ruth"})
+
+		poker.AssertFinishCalledWith(t, game, "Ruth")
+		poker.AssertGameStartedWith(t, game, 4)
+		poker.AssertGameStartedWithStructure(t, game, "turbo")
+	})
+
+	t.Run("a host that never comes back stops the blind alerts", func(t *testing.T) {
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		storage := tutils.NewStubStorage()
+		alerter := &poker.SpyBlindAlerter{}
+		game := poker.NewTexasHoldem(alerter, storage).WithClock(clock)
+
+		server := httptest.NewServer(mustMakePlayerServer(t, storage, game))
+		defer server.Close()
+		ws := mustDialWS(t, fmt.Sprintf("ws%s/ws", strings.TrimPrefix(server.URL, "http")))
+
+		sendWSMessage(t, ws, Message{Type: StartGameMsg, Players: 3})
+		readWSMessage(t, ws)
+		ws.Close()
+
+		clock.BlockUntil(1)
+		clock.Advance(poker.DefaultResumeWindow)
+
+		poker.AssertAlertsStopped(t, alerter)
+		if len(storage.WinCalls) != 0 {
+			t.Errorf("expected no winner, got %v", storage.WinCalls)
+		}
+	})
+
 	t.Run("start a game with 3 players and declare Ruth the winner", func(t *testing.T) {
 		wantedBlindAlert := "Blind is 100"
 		storage := tutils.NewStubStorage()
@@ -152,31 +726,458 @@ func TestLeague(t *testing.T) {
 			server.Close()
 		}()
 
-		writeWSMessage(t, ws, "3")
-		writeWSMessage(t, ws, winner)
+		sendWSMessage(t, ws, Message{Type: StartGameMsg, Players: 3})
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Winner: winner})
 
-		time.Sleep(tenMS)
-
-		poker.AssertGameStartedWith(t, game, 3)
 		poker.AssertFinishCalledWith(t, game, winner)
-		within(t, tenMS, func() { assertWebsocketGotMsg(t, ws, wantedBlindAlert) })
+		poker.AssertGameStartedWith(t, game, 3)
+
+		if got := readWSMessage(t, ws); got.Type != GameStartedMsg || got.Game != "1" || got.Players != 3 {
+			t.Errorf("got %+v, want game 1 started for 3", got)
+		}
+		// The spy's alert is written as it starts, before the host joins, and
+		// only blind changes are replayed to those joining later.
+		if got := readWSMessage(t, ws); got.Type != GameOverMsg || got.Winner != winner {
+			t.Errorf("got %+v, want game over with %s winning", got, winner)
+		}
+	})
+
+	t.Run("blind alerts reach the websocket as the clock moves", func(t *testing.T) {
+		clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+		storage := tutils.NewStubStorage()
+		game := poker.NewTexasHoldem(poker.NewAlerter(clock), storage).WithClock(clock)
+
+		server := httptest.NewServer(mustMakePlayerServer(t, storage, game))
+		ws := mustDialWS(t, fmt.Sprintf("ws%s/ws", strings.TrimPrefix(server.URL, "http")))
+
+		defer func() {
+			ws.Close()
+			server.Close()
+		}()
+
+		sendWSMessage(t, ws, Message{Type: StartGameMsg, Players: 5})
+		readWSMessage(t, ws)
+		clock.BlockUntil(11)
+
+		clock.Advance(10 * time.Minute)
+		assertBlindChanged(t, readWSMessage(t, ws), 100)
+		assertBlindChanged(t, readWSMessage(t, ws), 200)
 	})
 }
 
-func within(t testing.TB, d time.Duration, assert func()) {
-	t.Helper()
+func TestSpectators(t *testing.T) {
+	clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+	storage := tutils.NewStubStorage()
+	game := poker.NewTexasHoldem(poker.NewAlerter(clock), storage).WithClock(clock)
+	playerServer := mustMakePlayerServer(t, storage, game)
+
+	server := httptest.NewServer(playerServer)
+	defer server.Close()
+	wsURL := fmt.Sprintf("ws%s/ws?game=1", strings.TrimPrefix(server.URL, "http"))
+
+	resp := httptest.NewRecorder()
+	playerServer.ServeHTTP(resp, newCreateGameRequest(`{"Players": 5}`))
+	tutils.AssertStatus(t, resp, http.StatusCreated)
+	running, err := playerServer.games.Get("1")
+	tutils.AssertNoError(t, err)
+
+	tv, phone := mustDialWS(t, wsURL), mustDialWS(t, wsURL)
+	defer tv.Close()
+	defer phone.Close()
+	waitForViewers(t, running.Viewers, 2)
+	for _, ws := range []*websocket.Conn{tv, phone} {
+		if got := readWSMessage(t, ws); got.Type != GameStartedMsg || got.Game != "1" || got.Session != "" {
+			t.Errorf("got %+v, want the game's state without its session", got)
+		}
+	}
+
+	clock.Advance(10 * time.Minute)
+	for _, ws := range []*websocket.Conn{tv, phone} {
+		assertBlindChanged(t, readWSMessage(t, ws), 100)
+		assertBlindChanged(t, readWSMessage(t, ws), 200)
+	}
+
+	t.Run("a viewer joining mid-game sees the game's state and the current blind", func(t *testing.T) {
+		tutils.AssertNoError(t, running.Tournament.Record(leaguedb.EliminationEvent, "Chris"))
+		for _, ws := range []*websocket.Conn{tv, phone} {
+			if got := readWSMessage(t, ws); got.Type != PlayerEventMsg {
+				t.Errorf("got %+v, want the elimination", got)
+			}
+		}
+
+		laptop := mustDialWS(t, wsURL)
+		defer laptop.Close()
+
+		got := readWSMessage(t, laptop)
+		if got.Type != GameStartedMsg || got.Session != "" || got.Level == nil || got.Level.Blind != 200 || got.Remaining != 4 {
+			t.Errorf("got %+v, want the game's state with blind 200 and 4 players left", got)
+		}
+		assertBlindChanged(t, readWSMessage(t, laptop), 200)
+	})
+
+	t.Run("viewers can't control the game", func(t *testing.T) {
+		sendWSMessage(t, phone, Message{Type: PauseMsg})
+
+		assertProtocolError(t, readWSMessage(t, phone), ErrCodeReadOnly)
+		if running.Tournament.Paused() {
+			t.Error("a viewer paused the game")
+		}
+	})
+
+	t.Run("viewers hear the winner and are let go", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		playerServer.ServeHTTP(resp, newGameCommandRequest("1", `{"Winner": "Ruth"}`))
+		tutils.AssertStatus(t, resp, http.StatusNoContent)
+
+		if got := readWSMessage(t, tv); got.Type != GameOverMsg || got.Winner != "Ruth" {
+			t.Errorf("got %+v, want game over with Ruth winning", got)
+		}
+		if _, _, err := tv.ReadMessage(); err == nil {
+			t.Error("expected the connection to be closed")
+		}
+	})
+
+	t.Run("watching an unknown game", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err == nil || resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected a 404, got %v", err)
+		}
+	})
+}
+
+func TestWebSocketProtocol(t *testing.T) {
+	storage := tutils.NewStubStorage()
+	game := poker.NewTexasHoldem(poker.NewAlerter(clock.Real{}), storage)
+	server := httptest.NewServer(mustMakePlayerServer(t, storage, game))
+	defer server.Close()
+
+	dial := func(t *testing.T) *websocket.Conn {
+		ws := mustDialWS(t, fmt.Sprintf("ws%s/ws", strings.TrimPrefix(server.URL, "http")))
+		t.Cleanup(func() { ws.Close() })
+		return ws
+	}
+
+	t.Run("invalid messages get structured errors", func(t *testing.T) {
+		ws := dial(t)
+
+		cases := []struct {
+			name string
+			msg  string
+			code string
+		}{
+			{"not json", `5`, ErrCodeBadMessage},
+			{"another version", `{"Version": 2, "Type": "start_game", "Players": 5}`, ErrCodeUnsupportedVersion},
+			{"unknown type", `{"Version": 1, "Type": "shuffle"}`, ErrCodeUnknownType},
+			{"no players", `{"Version": 1, "Type": "start_game"}`, ErrCodeInvalidMessage},
+			{"no winner", `{"Version": 1, "Type": "declare_winner"}`, ErrCodeInvalidMessage},
+			{"winner not first", `{"Version": 1, "Type": "declare_winner", "Winner": "Ruth", "Placings": ["Chris", "Ruth"]}`, ErrCodeInvalidMessage},
+			{"unknown blind structure", `{"Version": 1, "Type": "start_game", "Players": 5, "Structure": "glacial"}`, ErrCodeInvalidMessage},
+			{"command before the game", `{"Version": 1, "Type": "pause"}`, ErrCodeNoGame},
+		}
+		for _, c := range cases {
+			writeWSMessage(t, ws, c.msg)
+			assertProtocolError(t, readWSMessage(t, ws), c.code)
+		}
+	})
+
+	t.Run("the connection survives errors and controls the game", func(t *testing.T) {
+		ws := dial(t)
+
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Winner: "Ruth"})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeNoGame)
+
+		sendWSMessage(t, ws, Message{Type: StartGameMsg, Players: 5})
+		started := readWSMessage(t, ws)
+		if started.Type != GameStartedMsg || started.Structure != poker.DefaultBlindStructure {
+			t.Fatalf("got %+v, want the game started with the default blinds", started)
+		}
+		assertBlindChanged(t, readWSMessage(t, ws), 100)
+
+		sendWSMessage(t, ws, Message{Type: StartGameMsg, Players: 5})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeGameRunning)
+
+		sendWSMessage(t, ws, Message{Type: NextLevelMsg})
+		assertBlindChanged(t, readWSMessage(t, ws), 200)
+
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Placings: []string{"Ruth", "Ruth"}})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeBadResult)
+
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Placings: []string{"Ruth", "Chris"}})
+		got := readWSMessage(t, ws)
+		if got.Type != GameOverMsg || got.Winner != "Ruth" || !reflect.DeepEqual(got.Placings, []string{"Ruth", "Chris"}) {
+			t.Errorf("got %+v, want game over with Ruth winning ahead of Chris", got)
+		}
+		tutils.AssertPlayerWin(t, storage, "Ruth")
+	})
+
+	t.Run("a game with named players only takes their results", func(t *testing.T) {
+		ws := dial(t)
+
+		sendWSMessage(t, ws, Message{Type: StartGameMsg, Names: []string{"Ruth", "Chris", "Cleo"}})
+		started := readWSMessage(t, ws)
+		if started.Type != GameStartedMsg || !reflect.DeepEqual(started.Names, []string{"Ruth", "Chris", "Cleo"}) {
+			t.Fatalf("got %+v, want the game started with Ruth, Chris and Cleo", started)
+		}
+		assertBlindChanged(t, readWSMessage(t, ws), 100)
+
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Winner: "Bob"})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeBadResult)
+
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Winner: "cleo"})
+		got := readWSMessage(t, ws)
+		if got.Type != GameOverMsg || got.Winner != "Cleo" {
+			t.Errorf("got %+v, want game over with Cleo winning", got)
+		}
+	})
+}
+
+func TestWebSocketPlayerEvents(t *testing.T) {
+	storage := tutils.NewStubStorage()
+	game := poker.NewTexasHoldem(poker.NewAlerter(clock.Real{}), storage)
+	server := httptest.NewServer(mustMakePlayerServer(t, storage, game))
+	defer server.Close()
+
+	ws := mustDialWS(t, fmt.Sprintf("ws%s/ws", strings.TrimPrefix(server.URL, "http")))
+	defer ws.Close()
+
+	sendWSMessage(t, ws, Message{Type: StartGameMsg, Names: []string{"Ruth", "Chris", "Cleo"}, StartingStack: 1000})
+	started := readWSMessage(t, ws)
+	if started.Remaining != 3 || started.AverageStack != 1000 || started.StartingStack != 1000 {
+		t.Fatalf("got %+v, want 3 players with 1000 chips each", started)
+	}
+	assertBlindChanged(t, readWSMessage(t, ws), 100)
+
+	t.Run("events are sent to everyone with the field", func(t *testing.T) {
+		sendWSMessage(t, ws, Message{Type: EliminateMsg, Player: "cleo"})
+		got := readWSMessage(t, ws)
+		want := Message{
+			Version:      ProtocolVersion,
+			Type:         PlayerEventMsg,
+			Event:        leaguedb.EliminationEvent,
+			Player:       "Cleo",
+			Text:         "Cleo is out, 2 players left, average stack 1500",
+			Remaining:    2,
+			AverageStack: 1500,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
 
-	done := make(chan struct{}, 1)
+		sendWSMessage(t, ws, Message{Type: RebuyMsg, Player: "Cleo"})
+		if got := readWSMessage(t, ws); got.Event != leaguedb.RebuyEvent || got.Remaining != 3 {
+			t.Errorf("got %+v, want Cleo back in", got)
+		}
+	})
+
+	t.Run("bad events get an error", func(t *testing.T) {
+		sendWSMessage(t, ws, Message{Type: AddOnMsg})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeInvalidMessage)
+
+		sendWSMessage(t, ws, Message{Type: EliminateMsg, Player: "Bob"})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeBadEvent)
+	})
+
+	t.Run("events are stored with the game", func(t *testing.T) {
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Winner: "Ruth"})
+		if got := readWSMessage(t, ws); got.Type != GameOverMsg {
+			t.Fatalf("got %+v, want game over", got)
+		}
+		if got := len(storage.Games[0].Events); got != 2 {
+			t.Errorf("got %d events stored, want 2", got)
+		}
+	})
+}
+
+func TestWebSocketPayouts(t *testing.T) {
+	storage := tutils.NewStubStorage()
+	game := poker.NewTexasHoldem(poker.NewAlerter(clock.Real{}), storage)
+	server := httptest.NewServer(mustMakePlayerServer(t, storage, game))
+	defer server.Close()
+
+	ws := mustDialWS(t, fmt.Sprintf("ws%s/ws", strings.TrimPrefix(server.URL, "http")))
+	defer ws.Close()
+
+	sendWSMessage(t, ws, Message{Type: StartGameMsg, Names: []string{"Ruth", "Chris", "Cleo"}, BuyIn: 10})
+	if started := readWSMessage(t, ws); started.BuyIn != 10 || started.PrizePool != 30 {
+		t.Fatalf("got %+v, want a prize pool of 30", started)
+	}
+	assertBlindChanged(t, readWSMessage(t, ws), 100)
+
+	sendWSMessage(t, ws, Message{Type: AddOnMsg, Player: "Ruth"})
+	readWSMessage(t, ws)
 
-	go func() {
-		assert()
-		done <- struct{}{}
-	}()
+	sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Placings: []string{"Ruth", "Cleo", "Chris"}})
 
-	select {
-	case <-time.After(d):
-		t.Error("timed out")
-	case <-done:
+	got := readWSMessage(t, ws)
+	want := []leaguedb.Payout{
+		{Place: 1, Player: "Ruth", Amount: 20},
+		{Place: 2, Player: "Cleo", Amount: 12},
+		{Place: 3, Player: "Chris", Amount: 8},
+	}
+	if got.Type != PayoutsMsg || got.PrizePool != 40 || !reflect.DeepEqual(got.Payouts, want) {
+		t.Errorf("got %+v, want payouts %v", got, want)
+	}
+	if got := readWSMessage(t, ws); got.Type != GameOverMsg {
+		t.Errorf("got %+v, want game over after the payouts", got)
+	}
+}
+
+func TestWebSocketHands(t *testing.T) {
+	storage := tutils.NewStubStorage()
+	game := poker.NewTexasHoldem(poker.NewAlerter(clock.Real{}), storage)
+	server := httptest.NewServer(mustMakePlayerServer(t, storage, game))
+	defer server.Close()
+
+	ws := mustDialWS(t, fmt.Sprintf("ws%s/ws", strings.TrimPrefix(server.URL, "http")))
+	defer ws.Close()
+
+	sendWSMessage(t, ws, Message{Type: StartGameMsg, Names: []string{"Ruth", "Chris", "Cleo"}, StartingStack: 1000})
+	started := readWSMessage(t, ws)
+	assertBlindChanged(t, readWSMessage(t, ws), 100)
+
+	t.Run("a hand is dealt with the blinds in play", func(t *testing.T) {
+		sendWSMessage(t, ws, Message{Type: DealHandMsg})
+
+		got := readWSMessage(t, ws)
+		if got.Type != HandMsg || got.Text != "Chris posts the small blind 50\nCleo posts the big blind 100" {
+			t.Fatalf("got %+v, want the blinds posted", got)
+		}
+		if got.Hand.ToAct != "Ruth" || got.Hand.ToCall != 100 || got.Hand.Pot != 150 {
+			t.Errorf("got hand %+v, want Ruth to call 100", got.Hand)
+		}
+		for _, seat := range got.Hand.Seats {
+			if len(seat.Cards) > 0 {
+				t.Errorf("got %s's cards %v sent to everyone", seat.Player, seat.Cards)
+			}
+		}
+	})
+
+	t.Run("players ask for their own cards with their seat", func(t *testing.T) {
+		phone := mustDialWS(t, fmt.Sprintf("ws%s/ws?game=%s", strings.TrimPrefix(server.URL, "http"), started.Game))
+		defer phone.Close()
+		if got := readWSMessage(t, phone); got.Session != "" || got.Seats != nil {
+			t.Errorf("got %+v, want the game's state without its secrets", got)
+		}
+		assertBlindChanged(t, readWSMessage(t, phone), 100)
+
+		sendWSMessage(t, phone, Message{Type: HoleCardsMsg, Seat: started.Seats["Ruth"]})
+		if got := readWSMessage(t, phone); got.Type != HoleCardsMsg || got.Player != "Ruth" || len(got.Cards) != 2 {
+			t.Errorf("got %+v, want Ruth's two cards", got)
+		}
+
+		sendWSMessage(t, phone, Message{Type: HoleCardsMsg, Seat: "guess"})
+		assertProtocolError(t, readWSMessage(t, phone), ErrCodeUnknownSeat)
+	})
+
+	t.Run("the host isn't sent anyone's cards", func(t *testing.T) {
+		sendWSMessage(t, ws, Message{Type: HoleCardsMsg, Seat: started.Seats["Ruth"]})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeInvalidMessage)
+	})
+
+	t.Run("actions out of turn get an error", func(t *testing.T) {
+		sendWSMessage(t, ws, Message{Type: ActMsg, Player: "Chris"})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeInvalidMessage)
+
+		sendWSMessage(t, ws, Message{Type: ActMsg, Player: "Chris", Action: poker.CallAction})
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeBadAction)
+	})
+
+	t.Run("the hand is played out over the websocket", func(t *testing.T) {
+		sendWSMessage(t, ws, Message{Type: ActMsg, Player: "Ruth", Action: poker.RaiseAction, Amount: 300})
+		if got := readWSMessage(t, ws); got.Text != "Ruth raises to 300" || got.Hand.ToAct != "Chris" {
+			t.Errorf("got %+v, want Ruth's raise and Chris to act", got)
+		}
+
+		sendWSMessage(t, ws, Message{Type: ActMsg, Player: "Chris", Action: poker.FoldAction})
+		readWSMessage(t, ws)
+		sendWSMessage(t, ws, Message{Type: ActMsg, Player: "Cleo", Action: poker.FoldAction})
+
+		got := readWSMessage(t, ws)
+		if !got.Hand.Over || got.Text != "Cleo folds\n200 is returned to Ruth\nRuth wins 250" {
+			t.Errorf("got %+v, want Ruth to win the blinds", got)
+		}
+	})
+}
+
+func TestWebSocketResume(t *testing.T) {
+	clock := tutils.NewFakeClock(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC))
+	storage := tutils.NewStubStorage()
+	game := poker.NewTexasHoldem(poker.NewAlerter(clock), storage).WithClock(clock)
+	server := httptest.NewServer(mustMakePlayerServer(t, storage, game))
+	defer server.Close()
+	wsURL := fmt.Sprintf("ws%s/ws", strings.TrimPrefix(server.URL, "http"))
+
+	startGame := func(t *testing.T) Message {
+		ws := mustDialWS(t, wsURL)
+		defer ws.Close()
+
+		sendWSMessage(t, ws, Message{Type: StartGameMsg, Players: 5})
+		started := readWSMessage(t, ws)
+		if started.Type != GameStartedMsg || started.Session == "" {
+			t.Fatalf("got %+v, want the game started with a session", started)
+		}
+		return started
+	}
+
+	t.Run("a reconnecting host picks up the blind and remaining time", func(t *testing.T) {
+		started := startGame(t)
+		clock.Advance(13 * time.Minute)
+
+		ws := mustDialWS(t, wsURL)
+		defer ws.Close()
+		sendWSMessage(t, ws, Message{Type: ResumeGameMsg, Session: started.Session})
+
+		resumed := readWSMessage(t, ws)
+		if resumed.Type != GameResumedMsg || resumed.Game != started.Game {
+			t.Fatalf("got %+v, want game %s resumed", resumed, started.Game)
+		}
+		if resumed.Level == nil || resumed.Level.Blind != 200 || resumed.RemainingSeconds != 7*60 {
+			t.Errorf("got %+v, want blind 200 with 7 minutes left", resumed)
+		}
+		assertBlindChanged(t, readWSMessage(t, ws), 200)
+		if len(storage.WinCalls) != 0 {
+			t.Errorf("expected no winner, got %v", storage.WinCalls)
+		}
+
+		sendWSMessage(t, ws, Message{Type: DeclareWinnerMsg, Winner: "Ruth"})
+		if got := readWSMessage(t, ws); got.Type != GameOverMsg {
+			t.Errorf("got %+v, want game over", got)
+		}
+		tutils.AssertPlayerWin(t, storage, "Ruth")
+	})
+
+	t.Run("a game left too long can't be resumed", func(t *testing.T) {
+		started := startGame(t)
+		clock.BlockUntil(12)
+		clock.Advance(poker.DefaultResumeWindow)
+
+		ws := mustDialWS(t, wsURL)
+		defer ws.Close()
+		sendWSMessage(t, ws, Message{Type: ResumeGameMsg, Session: started.Session})
+
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeUnknownSession)
+		if len(storage.WinCalls) != 1 {
+			t.Errorf("expected only Ruth's win, got %v", storage.WinCalls)
+		}
+	})
+
+	t.Run("unknown sessions", func(t *testing.T) {
+		ws := mustDialWS(t, wsURL)
+		defer ws.Close()
+		sendWSMessage(t, ws, Message{Type: ResumeGameMsg, Session: "guess"})
+
+		assertProtocolError(t, readWSMessage(t, ws), ErrCodeUnknownSession)
+	})
+}
+
+func waitForViewers(t testing.TB, hub *poker.Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for hub.Viewers() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d viewers, want %d", hub.Viewers(), n)
+		}
+		time.Sleep(time.Millisecond)
 	}
 }
 
@@ -195,6 +1196,43 @@ func newGetScoreRequest(name string) *http.Request {
 	return req
 }
 
+func newDeleteRequest(name string) *http.Request {
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/players/%s", name), nil)
+	return req
+}
+
+func newRenameRequest(name, body string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/players/%s", name), strings.NewReader(body))
+	return req
+}
+
+func newCreateGameRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/games", strings.NewReader(body))
+}
+
+func newGameCommandRequest(id, body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/games/"+id, strings.NewReader(body))
+}
+
+func newHandHistoryRequest(id, query string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/games/"+id+"/hands"+query, nil)
+}
+
+func newLedgerEntryRequest(id, body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/ledger/"+id, strings.NewReader(body))
+}
+
+func newOddsRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/odds", strings.NewReader(body))
+}
+
+func decodeJSON(t testing.TB, body io.Reader, v any) {
+	t.Helper()
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		t.Fatalf("Unable to parse response from server, '%v'", err)
+	}
+}
+
 func newLeagueRequest(t testing.TB) *http.Request {
 	req, err := http.NewRequest(http.MethodGet, "/league", nil)
 	if err != nil {
@@ -233,9 +1271,44 @@ func writeWSMessage(t testing.TB, conn *websocket.Conn, msg string) {
 	}
 }
 
-func assertWebsocketGotMsg(t *testing.T, ws *websocket.Conn, want string) {
-	_, msg, _ := ws.ReadMessage()
-	if string(msg) != want {
-		t.Errorf(`got "%s", want "%s"`, string(msg), want)
+func sendWSMessage(t testing.TB, ws *websocket.Conn, msg Message) {
+	t.Helper()
+	msg.Version = ProtocolVersion
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("could not encode message %v", err)
+	}
+	writeWSMessage(t, ws, string(data))
+}
+
+func readWSMessage(t testing.TB, ws *websocket.Conn) Message {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatalf("could not read from ws connection %v", err)
+	}
+
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("could not parse message %q, %v", data, err)
+	}
+	if msg.Version != ProtocolVersion {
+		t.Errorf("got version %d, want %d", msg.Version, ProtocolVersion)
+	}
+	return msg
+}
+
+func assertBlindChanged(t testing.TB, msg Message, blind int) {
+	t.Helper()
+	if msg.Type != BlindChangedMsg || msg.Level == nil || msg.Level.Blind != blind {
+		t.Errorf("got %+v, want the blind changed to %d", msg, blind)
+	}
+}
+
+func assertProtocolError(t testing.TB, msg Message, code string) {
+	t.Helper()
+	if msg.Type != ErrorMsg || msg.Error == nil || msg.Error.Code != code {
+		t.Errorf("got %+v, want an error with code %q", msg, code)
 	}
 }